@@ -3,9 +3,10 @@ package controllers
 import (
 	"log"
 	"net/http"
+	"strings"
 
-	"github.com/volons/hive/libs"
 	"github.com/volons/hive/libs/admin"
+	"github.com/volons/hive/libs/store"
 	"github.com/volons/hive/libs/websocket"
 	"github.com/volons/hive/models"
 )
@@ -15,27 +16,25 @@ func Admin(wsclient *websocket.Client, r *http.Request) *websocket.Error {
 	var token string
 	if t, ok := r.URL.Query()["token"]; ok && len(t) > 0 && len(t[0]) > 0 {
 		token = t[0]
+	} else if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
 	}
 
 	model, err := authenticateAdmin(token, wsclient)
 	if err != nil {
-		log.Println("vehicle ws: invalid token")
+		log.Printf("admin ws: authentication failed from %v: %v\n", r.RemoteAddr, err)
 		return websocket.NewError(err.Error(), 401)
 	}
 
 	adminConn := admin.New(wsclient)
 	go adminConn.Start(model, nil)
 
-	log.Printf("Admin '%v' connected\n", model.ID())
+	log.Printf("Admin '%v' (%v) connected\n", model.Name(), model.ID())
 
 	return nil
 }
 
-// authenticateVehicle creates a vehicle from a token
+// authenticateAdmin retreives an admin account from an api key
 func authenticateAdmin(token string, client *websocket.Client) (*models.Admin, error) {
-	return models.NewAdmin(libs.RandToken(8), ""), nil
-	//if token == "73tk91e2LtoSiPBzkeZalaUAG822Zis59E8f7oIZ738fT22RF1" {
-	//}
-
-	//return nil, errors.New("Invalid token")
+	return store.Admins.Authenticate(token)
 }
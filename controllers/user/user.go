@@ -39,11 +39,15 @@ func (u *User) Start(user *models.User, err error) {
 	u.user = user
 
 	ap := autopilot.Get(user.VehicleID())
-	ap.ConnectUser(u.autopilot)
+	ap.ConnectUser(user.ID(), user.Permissions(), u.autopilot)
+	if ap.ClaimControl(user.ID()) {
+		ap.StartOrResumeSession(user.ID())
+	}
 
 	u.Send(messages.New("update:login", libs.JSONObject{
 		"id":          u.user.ID(),
 		"permissions": u.user.Permissions(),
+		"caps":        ap.Caps(),
 	}))
 
 	u.run()
@@ -59,6 +63,8 @@ func (u *User) run() {
 
 		case <-u.user.Done():
 			u.Disconnect()
+		case <-u.autopilot.Done():
+			u.Disconnect()
 		case <-u.Done():
 			return
 		}
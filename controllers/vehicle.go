@@ -4,41 +4,67 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/volons/hive/libs/store"
 	"github.com/volons/hive/libs/websocket"
 	"github.com/volons/hive/models"
+	"github.com/volons/hive/models/config"
 	"github.com/volons/hive/nodes/vehicle"
 )
 
 // Vehicle websocket connection listener
 func Vehicle(wsclient *websocket.Client, r *http.Request) *websocket.Error {
-	var token string
-	if t, ok := r.URL.Query()["token"]; ok && len(t) > 0 && len(t[0]) > 0 {
-		token = t[0]
-	} else {
+	query := r.URL.Query()
+
+	id := query.Get("id")
+	token := query.Get("token")
+
+	// Vehicles of previous versions use their ID as token
+	if id == "" && config.Get().AllowUnprovisioned {
+		id = token
+	}
+
+	if id == "" {
+		log.Println("vehicle ws: no id provided")
+		return websocket.NewError("Need an id", 401)
+	}
+
+	if token == "" {
 		log.Println("vehicle ws: no token provided")
 		return websocket.NewError("Need a token", 401)
 	}
 
-	model, err := authenticateVehicle(token, wsclient)
+	model, err := authenticateVehicle(id, token, wsclient)
 	if err != nil {
-		log.Println("vehicle ws: invalid token", err)
+		log.Printf("vehicle ws: authentication of '%v' failed from %v: %v\n", id, r.RemoteAddr, err)
 		return websocket.NewError(err.Error(), 401)
 	}
 
+	takeover := query.Get("takeover") == "true"
+
 	vehicleNode := vehicle.New(wsclient)
-	go vehicleNode.Start(token, model)
+	err = vehicle.Register(id, vehicleNode, takeover)
+	if err != nil {
+		log.Println("vehicle ws:", err)
+		return websocket.NewError(err.Error(), 409)
+	}
+
+	go vehicleNode.Start(id, model)
 
 	return nil
 }
 
-// authenticateVehicle creates a vehicle from a token
-func authenticateVehicle(token string, client *websocket.Client) (*models.Vehicle, error) {
-	var vehicle models.Vehicle
+// authenticateVehicle retreives a provisioned vehicle from its id and secret token,
+// vehicles that were not provisioned are accepted when it is allowed by the config
+func authenticateVehicle(id, token string, client *websocket.Client) (*models.Vehicle, error) {
+	if config.Get().AllowUnprovisioned && !store.Vehicles.Provisioned(id) {
+		log.Printf("vehicle ws: '%v' connected without being provisioned\n", id)
 
-	//err := db.Get("vehicle:"+token, &vehicle)
-	//if err != nil && !db.IsNotFoudError(err) {
-	//	return nil, err
-	//}
+		vehicle := store.Vehicles.Get(id)
+		if vehicle == nil {
+			vehicle = &models.Vehicle{ID: id}
+		}
+		return vehicle, nil
+	}
 
-	return &vehicle, nil
+	return store.Vehicles.Authenticate(id, token)
 }
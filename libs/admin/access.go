@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// commandRoles maps each admin command to the minimum role needed to run it,
+// commands not listed here need the supervisor role
+var commandRoles = map[string]models.Role{
+	"fence:list":   models.RoleViewer,
+	"fence:events": models.RoleViewer,
+
+	"history:track":        models.RoleViewer,
+	"history:replay":       models.RoleViewer,
+	"history:replay:speed": models.RoleViewer,
+	"history:replay:stop":  models.RoleViewer,
+	"flights:list":         models.RoleViewer,
+	"telemetry:subscribe":  models.RoleViewer,
+	"mission:status":       models.RoleViewer,
+	"flight:export":        models.RoleViewer,
+	"mission:list":         models.RoleViewer,
+	"mission:get":          models.RoleViewer,
+	"mission:versions":     models.RoleViewer,
+	"mission:validate":     models.RoleViewer,
+	"mission:import":       models.RoleViewer,
+	"mission:export":       models.RoleViewer,
+	"fence:export":         models.RoleViewer,
+	"separation:status":    models.RoleViewer,
+
+	"location:open":   models.RoleOperator,
+	"fence:enable":    models.RoleOperator,
+	"fence:disable":   models.RoleOperator,
+	"session:extend":  models.RoleOperator,
+	"session:end":     models.RoleOperator,
+	"pilot:set":       models.RoleOperator,
+	"pilot:revoke":    models.RoleOperator,
+	"queue:subscribe": models.RoleOperator,
+	"queue:pick":      models.RoleOperator,
+	"queue:next":      models.RoleOperator,
+	"channel:open":    models.RoleOperator,
+	"channel:close":   models.RoleOperator,
+	"channel:send":    models.RoleOperator,
+	"mission:start":   models.RoleOperator,
+	"mission:pause":   models.RoleOperator,
+	"mission:resume":  models.RoleOperator,
+	"mission:abort":   models.RoleOperator,
+	"mission:save":    models.RoleOperator,
+	"mission:delete":  models.RoleOperator,
+	"mission:pattern": models.RoleOperator,
+	"follow:target":   models.RoleOperator,
+}
+
+// authorize checks if the admin is allowed to run the command
+// and to access the vehicle it targets
+func (a *Admin) authorize(msg messages.Message) error {
+	role, ok := commandRoles[msg.Type]
+	if !ok {
+		role = models.RoleSupervisor
+	}
+
+	if !a.admin.Can(role) {
+		return fmt.Errorf("'%s' needs the %s role", msg.Type, role)
+	}
+
+	if vehicleID := commandVehicleID(msg); vehicleID != "" && !a.admin.CanAccessVehicle(vehicleID) {
+		return fmt.Errorf("no access to vehicle '%s'", vehicleID)
+	}
+
+	return nil
+}
+
+// commandVehicleID returns the ID of the vehicle targeted by a command
+func commandVehicleID(msg messages.Message) string {
+	switch data := msg.Data.(type) {
+	case *models.VehicleFence:
+		return data.VehicleID
+	case *models.VehicleFenceParams:
+		return data.VehicleID
+	case *models.VehicleMission:
+		return data.VehicleID
+	case *models.MissionPlan:
+		return data.VehicleID
+	case *models.VehiclePattern:
+		return data.VehicleID
+	}
+
+	data := msg.JSONData()
+	if data == nil {
+		return ""
+	}
+
+	if vehicleID, ok := data.GetString("vehicleID"); ok {
+		return vehicleID
+	}
+
+	if channelID, ok := data.GetString("channelID"); ok {
+		if c := strings.SplitN(channelID, ":", 2); len(c) == 2 && c[0] == "vehicle" {
+			return c[1]
+		}
+	}
+
+	return ""
+}
+
+// checkRole checks that accounts created by this admin
+// do not get more rights than it has
+func (a *Admin) checkRole(role models.Role, vehicles []string) error {
+	if !a.admin.Can(role) {
+		return fmt.Errorf("cannot grant the %s role", role)
+	}
+
+	return a.checkScope(vehicles)
+}
+
+// checkScope checks that this admin has access to every vehicle
+// of a list, an empty list means every vehicle
+func (a *Admin) checkScope(vehicles []string) error {
+	if len(a.admin.Vehicles()) == 0 {
+		return nil
+	}
+
+	if len(vehicles) == 0 {
+		return fmt.Errorf("no access to every vehicle")
+	}
+
+	for _, vehicleID := range vehicles {
+		if !a.admin.CanAccessVehicle(vehicleID) {
+			return fmt.Errorf("no access to vehicle '%s'", vehicleID)
+		}
+	}
+
+	return nil
+}
@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/volons/hive/libs"
 	"github.com/volons/hive/libs/autopilot"
 	"github.com/volons/hive/libs/callback"
+	"github.com/volons/hive/libs/db"
 	"github.com/volons/hive/libs/store"
 	"github.com/volons/hive/messages"
 	"github.com/volons/hive/models"
+	"github.com/volons/hive/nodes/vehicle"
 	"github.com/volons/hive/platform"
 )
 
@@ -25,13 +28,19 @@ type Admin struct {
 	admin             *models.Admin
 	channels          map[string]*messages.Line
 	lastSentTelemetry time.Time
+	telemetry         telemetrySubscription
+	telemetrySubs     chan telemetrySubscription
+	replay            *replay
+	replayLock        sync.Mutex
 }
 
 // New creates a new admin
 func New(ch messages.Channel) *Admin {
 	c := &Admin{
-		ch:       ch,
-		channels: make(map[string]*messages.Line),
+		ch:            ch,
+		channels:      make(map[string]*messages.Line),
+		telemetry:     newTelemetrySubscription(),
+		telemetrySubs: make(chan telemetrySubscription),
 	}
 	return c
 }
@@ -50,6 +59,8 @@ func (a *Admin) Start(admin *models.Admin, err error) {
 	}
 
 	a.admin = admin
+	store.Admins.Connected(admin)
+	defer store.Admins.Disconnected(admin)
 
 	sendErr := a.ch.Send(messages.New("login", libs.JSONObject{
 		"id": a.admin.ID(),
@@ -69,8 +80,9 @@ func (a *Admin) run() {
 	vehiclesSub := store.Vehicles.Subscription()
 	defer store.Vehicles.Unsubscribe(vehiclesSub)
 
-	telemetrySub := time.NewTicker(time.Millisecond * 800)
-	defer telemetrySub.Stop()
+	// Telemetry is sent when it changes, at most once per interval
+	telemetryChanged := store.Vehicles.TelemetryChanged()
+	var telemetryTimer <-chan time.Time
 
 	usersSub := store.Users.Subscription()
 	defer store.Users.Unsubscribe(usersSub)
@@ -81,6 +93,12 @@ func (a *Admin) run() {
 	platformSub := platform.Platform.Subscription()
 	defer platform.Platform.Unsubscribe(platformSub)
 
+	fenceEventsSub := store.FenceEvents.Subscription()
+	defer store.FenceEvents.Unsubscribe(fenceEventsSub)
+
+	eventsSub := store.Events.Subscription()
+	defer store.Events.Unsubscribe(eventsSub)
+
 	for {
 		select {
 		case msg := <-a.ch.Recv():
@@ -88,7 +106,16 @@ func (a *Admin) run() {
 
 		case <-vehiclesSub.Recv():
 			a.onVehicleListChanged()
-		case <-telemetrySub.C:
+		case <-telemetryChanged:
+			telemetryChanged = nil
+			telemetryTimer = time.After(a.telemetry.interval - time.Since(a.lastSentTelemetry))
+		case <-telemetryTimer:
+			telemetryTimer = nil
+			telemetryChanged = store.Vehicles.TelemetryChanged()
+			a.sendTelemetry()
+		case sub := <-a.telemetrySubs:
+			a.telemetry = sub
+			a.lastSentTelemetry = time.Time{}
 			a.sendTelemetry()
 		case <-usersSub.Recv():
 			a.onUsersChanged()
@@ -97,11 +124,20 @@ func (a *Admin) run() {
 		case data := <-platformSub.Recv():
 			status := data.(platform.Status)
 			a.onPlatformStatus(status)
+		case data := <-fenceEventsSub.Recv():
+			event := data.(models.FenceEvent)
+			a.onFenceEvent(event)
+		case data := <-eventsSub.Recv():
+			event := data.(models.Event)
+			a.onEvent(event)
 
 		case <-a.admin.Done():
 			a.ch.Disconnect()
 		case <-a.ch.Done():
 			a.closeChannels()
+			if r := a.getReplay(); r != nil {
+				r.Stop()
+			}
 			return
 		}
 	}
@@ -113,12 +149,102 @@ func (a *Admin) onMessage(msg messages.Message) {
 		a.exec(a.openLocation, msg)
 	case "fence:set":
 		a.exec(a.setFence, msg)
+	case "fence:params":
+		a.exec(a.setFenceParams, msg)
+	case "fence:list":
+		a.exec(a.listFences, msg)
+	case "fence:clear":
+		a.exec(a.clearFence, msg)
 	case "fence:enable":
 		a.exec(a.enableFence, msg)
 	case "fence:disable":
 		a.exec(a.disableFence, msg)
+	case "fence:events":
+		a.exec(a.fenceEvents, msg)
+	case "nofly:set":
+		a.exec(a.setNoFly, msg)
 	case "permissions:set":
 		a.exec(a.setPermissions, msg)
+	case "session:config":
+		a.exec(a.configSession, msg)
+	case "battery:config":
+		a.exec(a.configBattery, msg)
+	case "failsafe:config":
+		a.exec(a.configFailsafe, msg)
+	case "session:extend":
+		a.exec(a.extendSession, msg)
+	case "session:end":
+		a.exec(a.endSession, msg)
+	case "pilot:set":
+		a.exec(a.setPilot, msg)
+	case "pilot:revoke":
+		a.exec(a.revokePilot, msg)
+	case "admin:create":
+		a.exec(a.createAdmin, msg)
+	case "admin:update":
+		a.exec(a.updateAdmin, msg)
+	case "admin:revoke":
+		a.exec(a.revokeAdmin, msg)
+	case "admin:list":
+		a.exec(a.listAdmins, msg)
+	case "vehicle:provision":
+		a.exec(a.provisionVehicle, msg)
+	case "vehicle:revoke":
+		a.exec(a.revokeVehicle, msg)
+	case "history:track":
+		a.exec(a.historyTrack, msg)
+	case "history:replay":
+		a.exec(a.startReplay, msg)
+	case "history:replay:speed":
+		a.exec(a.setReplaySpeed, msg)
+	case "history:replay:stop":
+		a.exec(a.stopReplay, msg)
+	case "mission:start":
+		a.exec(a.startMission, msg)
+	case "mission:pause":
+		a.exec(a.pauseMission, msg)
+	case "mission:resume":
+		a.exec(a.resumeMission, msg)
+	case "mission:abort":
+		a.exec(a.abortMission, msg)
+	case "mission:status":
+		a.exec(a.missionStatus, msg)
+	case "mission:pattern":
+		a.exec(a.startPattern, msg)
+	case "follow:target":
+		a.exec(a.setFollowTarget, msg)
+	case "mission:save":
+		a.exec(a.saveMissionPlan, msg)
+	case "mission:validate":
+		a.exec(a.validateMissionPlan, msg)
+	case "mission:get":
+		a.exec(a.getMissionPlan, msg)
+	case "mission:versions":
+		a.exec(a.missionPlanVersions, msg)
+	case "mission:list":
+		a.exec(a.listMissionPlans, msg)
+	case "mission:delete":
+		a.exec(a.deleteMissionPlan, msg)
+	case "mission:config":
+		a.exec(a.configMission, msg)
+	case "mission:import":
+		a.exec(a.importPlan, msg)
+	case "mission:export":
+		a.exec(a.exportMissionPlan, msg)
+	case "fence:export":
+		a.exec(a.exportFence, msg)
+	case "separation:config":
+		a.exec(a.configSeparation, msg)
+	case "separation:status":
+		a.exec(a.separationStatus, msg)
+	case "flights:list":
+		a.exec(a.listFlights, msg)
+	case "flight:export":
+		a.exec(a.exportFlight, msg)
+	case "telemetry:subscribe":
+		a.exec(a.subscribeTelemetry, msg)
+	case "audit:query":
+		a.exec(a.queryAudit, msg)
 	case "queue:subscribe":
 		a.exec(a.queueSubscribe, msg)
 	case "queue:pick":
@@ -137,28 +263,31 @@ func (a *Admin) onMessage(msg messages.Message) {
 }
 
 func (a *Admin) exec(fn cmd, msg messages.Message) {
+	err := a.authorize(msg)
+	if err != nil {
+		a.reply(msg.ID, nil, err)
+		go a.audit(msg, nil, err)
+		return
+	}
+
 	go func() {
 		res, err := fn(msg)
 		a.reply(msg.ID, res, err)
+		a.audit(msg, res, err)
 	}()
 }
 
 func (a *Admin) onVehicleListChanged() {
-	err := a.ch.Send(messages.New("vehicles", store.Vehicles.JSON()))
-	if err != nil {
-		log.Println(err)
+	vehicles := store.Vehicles.JSON()
+	for vehicleID := range vehicles {
+		if !a.admin.CanAccessVehicle(vehicleID) {
+			delete(vehicles, vehicleID)
+		}
 	}
-}
 
-func (a *Admin) sendTelemetry() {
-	pos := store.Vehicles.TelemetryJSON(a.lastSentTelemetry)
-	if len(pos) > 0 {
-		err := a.ch.Send(messages.New("telemetry", pos))
-		if err != nil {
-			log.Println(err)
-		} else {
-			a.lastSentTelemetry = time.Now()
-		}
+	err := a.ch.Send(messages.New("vehicles", vehicles))
+	if err != nil {
+		log.Println(err)
 	}
 }
 
@@ -183,6 +312,203 @@ func (a *Admin) onPlatformStatus(status platform.Status) {
 	}
 }
 
+func (a *Admin) onFenceEvent(event models.FenceEvent) {
+	if !a.admin.CanAccessVehicle(event.VehicleID) {
+		return
+	}
+
+	err := a.ch.Send(messages.New("fence:event", event))
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (a *Admin) onEvent(event models.Event) {
+	if !a.admin.CanAccessVehicle(event.VehicleID) {
+		return
+	}
+
+	err := a.ch.Send(messages.New("event", event))
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (a *Admin) createAdmin(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need name")
+	}
+
+	name, _ := data.GetString("name")
+
+	role := models.RoleViewer
+	if str, ok := data.GetString("role"); ok {
+		role = models.Role(str)
+	}
+
+	vehicles, err := getStrings(data, "vehicles")
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.checkRole(role, vehicles)
+	if err != nil {
+		return nil, err
+	}
+
+	admin, key, err := store.Admins.Create(name, role, vehicles)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Admin '%v' created %v '%v' (%v)\n", a.admin.ID(), role, admin.Name(), admin.ID())
+
+	return libs.JSONObject{
+		"admin": admin.Info(),
+		"key":   key,
+	}, nil
+}
+
+func (a *Admin) updateAdmin(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need id")
+	}
+
+	id, ok := data.GetString("id")
+	if !ok {
+		return nil, errors.New("need id")
+	}
+
+	admin := store.Admins.Get(id)
+	if admin == nil {
+		return nil, fmt.Errorf("unknown admin with ID '%s'", id)
+	}
+
+	err := a.checkScope(admin.Vehicles())
+	if err != nil {
+		return nil, err
+	}
+
+	role := admin.Role()
+	if str, ok := data.GetString("role"); ok {
+		role = models.Role(str)
+	}
+
+	vehicles := admin.Vehicles()
+	if data.HasKey("vehicles") {
+		var err error
+		vehicles, err = getStrings(data, "vehicles")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = a.checkRole(role, vehicles)
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err = store.Admins.SetAccess(id, role, vehicles)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Admin '%v' set admin '%v' to %v on vehicles %v\n", a.admin.ID(), id, role, vehicles)
+
+	return admin.Info(), nil
+}
+
+func (a *Admin) revokeAdmin(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need id")
+	}
+
+	id, ok := data.GetString("id")
+	if !ok {
+		return nil, errors.New("need id")
+	}
+
+	admin := store.Admins.Get(id)
+	if admin == nil {
+		return nil, fmt.Errorf("unknown admin with ID '%s'", id)
+	}
+
+	err := a.checkScope(admin.Vehicles())
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.Admins.Revoke(id)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Admin '%v' revoked admin '%v'\n", a.admin.ID(), id)
+
+	return nil, nil
+}
+
+func (a *Admin) listAdmins(msg messages.Message) (interface{}, error) {
+	admins := []models.AdminInfo{}
+	for _, admin := range store.Admins.JSON() {
+		if a.checkScope(admin.Vehicles) == nil {
+			admins = append(admins, admin)
+		}
+	}
+
+	return admins, nil
+}
+
+func (a *Admin) provisionVehicle(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need vehicleID")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	rotate, _ := data.GetBool("rotate")
+
+	secret, err := store.Vehicles.Provision(vehicleID, rotate)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Admin '%v' provisioned vehicle '%v'\n", a.admin.ID(), vehicleID)
+
+	return libs.JSONObject{
+		"vehicleID": vehicleID,
+		"token":     secret,
+	}, nil
+}
+
+func (a *Admin) revokeVehicle(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need vehicleID")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	err := store.Vehicles.Revoke(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Admin '%v' revoked vehicle '%v'\n", a.admin.ID(), vehicleID)
+
+	return map[string]bool{"wasConnected": vehicle.Disconnect(vehicleID)}, nil
+}
+
 func (a *Admin) queueSubscribe(msg messages.Message) (interface{}, error) {
 	return nil, platform.Platform.QueueSubscribe()
 }
@@ -193,25 +519,156 @@ func (a *Admin) openLocation(msg messages.Message) (interface{}, error) {
 		data = libs.JSONObject{}
 	}
 
-	fence := models.GetFence()
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	fence := models.GetFence(vehicleID)
 	if fence == nil {
 		return nil, errors.New("Fence is not set")
 	}
 
 	data["fence"] = fence.JSON()
+	if noFly := models.GetNoFlyZones(); noFly != nil {
+		data["nofly"] = noFly.JSON()
+	}
 	msg.Data = data
 
 	return nil, platform.Platform.OpenLocation(data)
 }
 
 func (a *Admin) setFence(msg messages.Message) (interface{}, error) {
-	fence, ok := msg.Data.(*models.FenceData)
-	if ok {
-		return nil, models.SetFence(*fence)
+	fence, ok := msg.Data.(*models.VehicleFence)
+	if !ok {
+		log.Println("setFence bad: ", msg.Data)
+		return nil, errors.New("bad fence data format")
+	}
+
+	if fence.VehicleID == "" {
+		return nil, errors.New("need vehicleID")
+	}
+
+	ap, err := autopilot.Lookup(fence.VehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.Fences.Set(fence.VehicleID, fence.Fence)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Println("setFence bad: ", fence)
-	return nil, errors.New("bad fence data format")
+	return nil, ap.Push(messages.New("fence:update", nil))
+}
+
+func (a *Admin) setFenceParams(msg messages.Message) (interface{}, error) {
+	params, ok := msg.Data.(*models.VehicleFenceParams)
+	if !ok {
+		return nil, errors.New("bad fence params data format")
+	}
+
+	if params.VehicleID == "" {
+		return nil, errors.New("need vehicleID")
+	}
+
+	ap, err := autopilot.Lookup(params.VehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.Fences.SetParams(params.VehicleID, params.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, ap.Push(messages.New("fence:update", nil))
+}
+
+func (a *Admin) listFences(msg messages.Message) (interface{}, error) {
+	fences := store.Fences.JSON()
+	for vehicleID := range fences {
+		if !a.admin.CanAccessVehicle(vehicleID) {
+			delete(fences, vehicleID)
+		}
+	}
+
+	return fences, nil
+}
+
+func (a *Admin) clearFence(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("no data")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	ap, err := autopilot.Lookup(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	cleared, err := store.Fences.Clear(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ap.Push(messages.New("fence:disable", nil))
+	if err != nil {
+		log.Println(err)
+	}
+
+	return map[string]bool{"wasSet": cleared}, nil
+}
+
+func (a *Admin) fenceEvents(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		data = libs.JSONObject{}
+	}
+
+	vehicleID, _ := data.GetString("vehicleID")
+	from, to, err := getTimeRange(data)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := store.FenceEvents.Find(vehicleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := []models.FenceEvent{}
+	for _, event := range events {
+		if a.admin.CanAccessVehicle(event.VehicleID) {
+			allowed = append(allowed, event)
+		}
+	}
+
+	return allowed, nil
+}
+
+func (a *Admin) setNoFly(msg messages.Message) (interface{}, error) {
+	// no fly zones apply to every vehicle
+	if len(a.admin.Vehicles()) > 0 {
+		return nil, errors.New("no fly zones need access to every vehicle")
+	}
+
+	noFly, ok := msg.Data.(*models.NoFlyData)
+	if !ok {
+		return nil, errors.New("bad no fly zones data format")
+	}
+
+	err := models.SetNoFlyZones(*noFly)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, db.Set("nofly", *noFly)
 }
 
 func (a *Admin) enableFence(msg messages.Message) (interface{}, error) {
@@ -225,9 +682,9 @@ func (a *Admin) enableFence(msg messages.Message) (interface{}, error) {
 		return nil, errors.New("need vehicleID")
 	}
 
-	ap := autopilot.Get(vehicleID)
-	if ap == nil {
-		return nil, errors.New("Vehicle does not exist")
+	ap, err := autopilot.Lookup(vehicleID)
+	if err != nil {
+		return nil, err
 	}
 
 	return nil, ap.Push(messages.New("fence:enable", nil))
@@ -244,16 +701,278 @@ func (a *Admin) disableFence(msg messages.Message) (interface{}, error) {
 		return nil, errors.New("need vehicleID")
 	}
 
-	ap := autopilot.Get(vehicleID)
-	if ap == nil {
-		return nil, errors.New("Vehicle does not exist")
+	ap, err := autopilot.Lookup(vehicleID)
+	if err != nil {
+		return nil, err
 	}
 
 	return nil, ap.Push(messages.New("fence:disable", nil))
 }
 
 func (a *Admin) setPermissions(msg messages.Message) (interface{}, error) {
-	return nil, nil
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need userID and permissions")
+	}
+
+	userID, ok := data.GetString("userID")
+	if !ok {
+		return nil, errors.New("need userID")
+	}
+
+	perms, ok := data.GetObj("permissions")
+	if !ok {
+		return nil, errors.New("need permissions")
+	}
+
+	permissions := models.Permissions{}
+	for feature, val := range perms {
+		allowed, ok := val.(bool)
+		if !ok {
+			return nil, fmt.Errorf("permission '%v' should be a boolean", feature)
+		}
+		permissions.Set(feature, allowed)
+	}
+
+	user := store.Users.GetByID(userID)
+	if user == nil {
+		return nil, fmt.Errorf("unknown user with ID '%s'", userID)
+	}
+	if !a.admin.CanAccessVehicle(user.VehicleID()) {
+		return nil, fmt.Errorf("no access to vehicle '%s'", user.VehicleID())
+	}
+
+	user.SetPermissions(permissions)
+	store.Users.Save(user)
+
+	if ap, err := autopilot.Lookup(user.VehicleID()); err == nil {
+		ap.SetPermissions(user.ID(), user.Permissions())
+	}
+
+	return user.Permissions(), nil
+}
+
+func (a *Admin) configSession(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("no data")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	settings := store.Vehicles.Settings(vehicleID)
+	if duration, ok := data.GetNumber("duration"); ok {
+		settings.Session.Duration = duration
+	}
+	if endAction, ok := data.GetString("endAction"); ok {
+		settings.Session.EndAction = endAction
+	}
+
+	err := settings.Session.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return settings.Session, store.Vehicles.SetSettings(vehicleID, settings)
+}
+
+func (a *Admin) configBattery(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("no data")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	settings := store.Vehicles.Settings(vehicleID)
+	if warning, ok := data.GetNumber("warning"); ok {
+		settings.Battery.Warning = warning
+	}
+	if critical, ok := data.GetNumber("critical"); ok {
+		settings.Battery.Critical = critical
+	}
+	if emergency, ok := data.GetNumber("emergency"); ok {
+		settings.Battery.Emergency = emergency
+	}
+	if hysteresis, ok := data.GetNumber("hysteresis"); ok {
+		settings.Battery.Hysteresis = hysteresis
+	}
+
+	err := settings.Battery.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return settings.Battery, store.Vehicles.SetSettings(vehicleID, settings)
+}
+
+func (a *Admin) configFailsafe(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("no data")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	settings := store.Vehicles.Settings(vehicleID)
+	if hold, ok := data.GetNumber("hold"); ok {
+		settings.Failsafe.Hold = hold
+	}
+	if rtl, ok := data.GetNumber("rtl"); ok {
+		settings.Failsafe.RTL = rtl
+	}
+	if telemetry, ok := data.GetNumber("telemetry"); ok {
+		settings.Failsafe.Telemetry = telemetry
+	}
+
+	err := settings.Failsafe.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return settings.Failsafe, store.Vehicles.SetSettings(vehicleID, settings)
+}
+
+func (a *Admin) configMission(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("no data")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	settings := store.Vehicles.Settings(vehicleID)
+	if maxDistance, ok := data.GetNumber("maxDistance"); ok {
+		settings.Mission.MaxDistance = maxDistance
+	}
+	if maxAltitude, ok := data.GetNumber("maxAltitude"); ok {
+		settings.Mission.MaxAltitude = maxAltitude
+	}
+	if speed, ok := data.GetNumber("speed"); ok {
+		settings.Mission.Speed = speed
+	}
+	if endurance, ok := data.GetNumber("endurance"); ok {
+		settings.Mission.Endurance = endurance
+	}
+	if reserve, ok := data.GetNumber("reserve"); ok {
+		settings.Mission.Reserve = reserve
+	}
+
+	err := settings.Mission.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return settings.Mission, store.Vehicles.SetSettings(vehicleID, settings)
+}
+
+func (a *Admin) extendSession(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("no data")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	duration, ok := data.GetNumber("duration")
+	if !ok {
+		return nil, errors.New("need duration")
+	}
+
+	ap, err := autopilot.Lookup(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeLeft, err := ap.ExtendSession(time.Duration(duration * float64(time.Second)))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]float64{"timeLeft": timeLeft.Seconds()}, nil
+}
+
+func (a *Admin) endSession(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("no data")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	ap, err := autopilot.Lookup(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, ap.EndSession()
+}
+
+func (a *Admin) setPilot(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need vehicleID and userID")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	userID, ok := data.GetString("userID")
+	if !ok || userID == "" {
+		return nil, errors.New("need userID")
+	}
+
+	ap, err := autopilot.Lookup(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ap.HandOver(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"pilot": ap.GetPilot(), "users": ap.GetUsers()}, nil
+}
+
+func (a *Admin) revokePilot(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need vehicleID")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	ap, err := autopilot.Lookup(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	ap.SetPilot("")
+
+	return map[string]interface{}{"pilot": ap.GetPilot(), "users": ap.GetUsers()}, nil
 }
 
 func (a *Admin) openChannel(msg messages.Message) (interface{}, error) {
@@ -271,13 +990,13 @@ func (a *Admin) openChannel(msg messages.Message) (interface{}, error) {
 	typ, id := c[0], c[1]
 
 	if typ == "vehicle" {
-		ap := autopilot.Get(id)
-		if ap == nil {
-			return nil, fmt.Errorf("unknown vehicle with ID '%s'", id)
+		ap, err := autopilot.Lookup(id)
+		if err != nil {
+			return nil, err
 		}
 
 		line := messages.NewLine("channel:" + channelID, true)
-		ap.ConnectUser(line)
+		ap.ConnectUser(a.channelUserID(), models.AllPermissions(), line)
 		a.channels[channelID] = line
 
 		go func() {
@@ -306,6 +1025,12 @@ func (a *Admin) openChannel(msg messages.Message) (interface{}, error) {
 	return "OK", nil
 }
 
+// channelUserID returns the user ID with which this admin's
+// channels are connected to vehicles
+func (a *Admin) channelUserID() string {
+	return "admin:" + a.admin.ID()
+}
+
 func (a *Admin) sendOnChannel(msg messages.Message) (interface{}, error) {
 	data := msg.JSONData()
 	if data == nil {
@@ -425,6 +1150,48 @@ func (a *Admin) unknownMessage(msg messages.Message) (interface{}, error) {
 	return nil, fmt.Errorf("Unknown message of type %s", msg.Type)
 }
 
+// getTimeRange reads the optional "from" and "to"
+// RFC 3339 time parameters of a message
+func getTimeRange(data libs.JSONObject) (from time.Time, to time.Time, err error) {
+	if str, ok := data.GetString("from"); ok {
+		from, err = time.Parse(time.RFC3339, str)
+		if err != nil {
+			return from, to, fmt.Errorf("bad from parameter: %v", err)
+		}
+	}
+
+	if str, ok := data.GetString("to"); ok {
+		to, err = time.Parse(time.RFC3339, str)
+		if err != nil {
+			return from, to, fmt.Errorf("bad to parameter: %v", err)
+		}
+	}
+
+	return from, to, nil
+}
+
+// getStrings reads an optional array of strings parameter of a message
+func getStrings(data libs.JSONObject, key string) ([]string, error) {
+	arr, ok := data.GetArray(key)
+	if !ok {
+		if data.HasKey(key) && data[key] != nil {
+			return nil, fmt.Errorf("%s should be an array", key)
+		}
+		return nil, nil
+	}
+
+	out := []string{}
+	for _, val := range arr {
+		str, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s should only contain strings", key)
+		}
+		out = append(out, str)
+	}
+
+	return out, nil
+}
+
 func (a *Admin) reply(msgID string, result interface{}, err error) {
 	data := libs.JSONObject{
 		"id": msgID,
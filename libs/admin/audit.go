@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// unauditedReplies lists the commands whose reply is not kept in the
+// audit log because it contains secrets or is only a read of stored data
+var unauditedReplies = map[string]bool{
+	"admin:create":      true,
+	"admin:list":        true,
+	"vehicle:provision": true,
+	"fence:list":        true,
+	"fence:events":      true,
+	"history:track":     true,
+	"flights:list":      true,
+	"flight:export":     true,
+	"audit:query":       true,
+	"mission:list":      true,
+	"mission:get":       true,
+	"mission:versions":  true,
+	"mission:validate":  true,
+	"mission:import":    true,
+	"mission:export":    true,
+	"fence:export":      true,
+	"separation:status": true,
+}
+
+// audit appends an admin command and its outcome to the audit log
+func (a *Admin) audit(msg messages.Message, res interface{}, err error) {
+	entry := models.NewAuditEntry(models.AuditSourceAdmin, a.admin.ID(), commandVehicleID(msg), msg.Type, msg.Data)
+	if unauditedReplies[msg.Type] {
+		res = nil
+	}
+	entry.SetResult(res, err)
+
+	store.Audit.Add(entry)
+}
+
+func (a *Admin) queryAudit(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		data = libs.JSONObject{}
+	}
+
+	vehicleID, _ := data.GetString("vehicleID")
+	from, to, err := getTimeRange(data)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := store.Audit.Find(vehicleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := []models.AuditEntry{}
+	for _, entry := range entries {
+		if entry.VehicleID == "" || a.admin.CanAccessVehicle(entry.VehicleID) {
+			allowed = append(allowed, entry)
+		}
+	}
+
+	return allowed, nil
+}
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/formats"
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+func (a *Admin) listFlights(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		data = libs.JSONObject{}
+	}
+
+	vehicleID, _ := data.GetString("vehicleID")
+	from, to, err := getTimeRange(data)
+	if err != nil {
+		return nil, err
+	}
+
+	flights, err := store.Flights.Find(vehicleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := []models.Flight{}
+	for _, flight := range flights {
+		if a.admin.CanAccessVehicle(flight.VehicleID) {
+			allowed = append(allowed, flight)
+		}
+	}
+
+	return allowed, nil
+}
+
+func (a *Admin) exportFlight(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need vehicleID and flightID")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	flightID, ok := data.GetString("flightID")
+	if !ok {
+		return nil, errors.New("need flightID")
+	}
+
+	format, _ := data.GetString("format")
+
+	var encode func(string, []models.Position) ([]byte, error)
+	switch format {
+	case "gpx", "":
+		format, encode = "gpx", formats.GPX
+	case "kml":
+		encode = formats.KML
+	default:
+		return nil, fmt.Errorf("unknown format '%s'", format)
+	}
+
+	flight := store.Flights.Get(vehicleID, flightID)
+	if flight == nil {
+		return nil, fmt.Errorf("unknown flight with ID '%s'", flightID)
+	}
+
+	track, err := store.Flights.Track(flight)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s %s", vehicleID, flight.Start.Format("2006-01-02 15:04"))
+	out, err := encode(name, track)
+	if err != nil {
+		return nil, err
+	}
+
+	return libs.JSONObject{
+		"format":   format,
+		"filename": fmt.Sprintf("%s-%s.%s", vehicleID, flight.ID, format),
+		"data":     string(out),
+	}, nil
+}
@@ -0,0 +1,159 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// maxReplaySpeed is the highest speed factor of a replay
+var maxReplaySpeed = float64(100)
+
+func (a *Admin) historyTrack(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need vehicleID")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	from, to, err := getTimeRange(data)
+	if err != nil {
+		return nil, err
+	}
+
+	samples, err := store.History.Find(vehicleID, models.SamplePosition, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	track := []models.Position{}
+	for _, sample := range samples {
+		track = append(track, *sample.Position)
+	}
+
+	return track, nil
+}
+
+func (a *Admin) startReplay(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need vehicleID")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	speed := float64(1)
+	if val, ok := data.GetNumber("speed"); ok {
+		speed = val
+	}
+	if err := checkReplaySpeed(speed); err != nil {
+		return nil, err
+	}
+
+	from, to, err := getTimeRange(data)
+	if err != nil {
+		return nil, err
+	}
+
+	samples, err := store.History.Find(vehicleID, "", from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("no telemetry recorded in this time range")
+	}
+
+	r := newReplay(vehicleID, samples, speed)
+
+	a.replayLock.Lock()
+	if a.replay != nil {
+		a.replay.Stop()
+	}
+	a.replay = r
+	a.replayLock.Unlock()
+
+	go func() {
+		r.run(a.ch.Send)
+
+		a.replayLock.Lock()
+		if a.replay == r {
+			a.replay = nil
+		}
+		a.replayLock.Unlock()
+
+		err := a.ch.Send(messages.New("history:replay:end", libs.JSONObject{
+			"vehicleID": vehicleID,
+		}))
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	return libs.JSONObject{
+		"vehicleID": vehicleID,
+		"from":      samples[0].Timestamp,
+		"to":        samples[len(samples)-1].Timestamp,
+		"samples":   len(samples),
+	}, nil
+}
+
+func (a *Admin) setReplaySpeed(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need speed")
+	}
+
+	speed, ok := data.GetNumber("speed")
+	if !ok {
+		return nil, errors.New("need speed")
+	}
+	if err := checkReplaySpeed(speed); err != nil {
+		return nil, err
+	}
+
+	r := a.getReplay()
+	if r == nil || !r.SetSpeed(speed) {
+		return nil, errors.New("no replay running")
+	}
+
+	return nil, nil
+}
+
+func (a *Admin) stopReplay(msg messages.Message) (interface{}, error) {
+	r := a.getReplay()
+	if r == nil {
+		return map[string]bool{"wasRunning": false}, nil
+	}
+
+	r.Stop()
+
+	return map[string]bool{"wasRunning": true}, nil
+}
+
+// getReplay returns the running replay or nil
+func (a *Admin) getReplay() *replay {
+	a.replayLock.Lock()
+	defer a.replayLock.Unlock()
+
+	return a.replay
+}
+
+func checkReplaySpeed(speed float64) error {
+	if speed <= 0 || speed > maxReplaySpeed {
+		return fmt.Errorf("speed should be above 0 and at most %v", maxReplaySpeed)
+	}
+
+	return nil
+}
@@ -0,0 +1,284 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/autopilot"
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+func (a *Admin) startMission(msg messages.Message) (interface{}, error) {
+	mission, ok := msg.Data.(*models.VehicleMission)
+	if !ok {
+		return nil, errors.New("bad mission data format")
+	}
+
+	if mission.VehicleID == "" {
+		return nil, errors.New("need vehicleID")
+	}
+
+	if mission.PlanID != "" {
+		plan := store.MissionPlans.Get(mission.PlanID, 0)
+		if plan == nil {
+			return nil, fmt.Errorf("unknown mission plan with ID '%s'", mission.PlanID)
+		}
+		if plan.VehicleID != "" && plan.VehicleID != mission.VehicleID {
+			return nil, fmt.Errorf("mission plan is for vehicle '%s'", plan.VehicleID)
+		}
+		mission.Mission = plan.Runnable()
+	}
+
+	ap, err := autopilot.Lookup(mission.VehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ap.StartMission(mission.Mission)
+	if errs, ok := err.(models.WaypointErrors); ok {
+		return libs.JSONObject{"errors": errs}, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ap.MissionProgress(), nil
+}
+
+// startPattern runs an orbit or a survey as a mission, or starts following a target
+func (a *Admin) startPattern(msg messages.Message) (interface{}, error) {
+	pattern, ok := msg.Data.(*models.VehiclePattern)
+	if !ok {
+		return nil, errors.New("bad pattern data format")
+	}
+
+	if pattern.VehicleID == "" {
+		return nil, errors.New("need vehicleID")
+	}
+
+	ap, err := autopilot.Lookup(pattern.VehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var mission models.Mission
+	switch {
+	case pattern.Orbit != nil:
+		mission, err = pattern.Orbit.Mission()
+	case pattern.Survey != nil:
+		mission, err = pattern.Survey.Mission()
+	case pattern.Follow != nil:
+		err = ap.StartFollow(*pattern.Follow)
+		if err != nil {
+			return nil, err
+		}
+		return ap.MissionProgress(), nil
+	default:
+		return nil, errors.New("need orbit, survey or follow")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = ap.StartMission(mission)
+	if errs, ok := err.(models.WaypointErrors); ok {
+		return libs.JSONObject{"errors": errs}, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ap.MissionProgress(), nil
+}
+
+func (a *Admin) setFollowTarget(msg messages.Message) (interface{}, error) {
+	ap, err := missionAutopilot(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	data := msg.JSONData()
+	lat, ok := data.GetNumber("lat")
+	if !ok {
+		return nil, errors.New("need lat")
+	}
+	lon, ok := data.GetNumber("lon")
+	if !ok {
+		return nil, errors.New("need lon")
+	}
+
+	return nil, ap.SetFollowTarget(models.NewPoint(lat, lon, 0))
+}
+
+func (a *Admin) pauseMission(msg messages.Message) (interface{}, error) {
+	ap, err := missionAutopilot(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, ap.PauseMission()
+}
+
+func (a *Admin) resumeMission(msg messages.Message) (interface{}, error) {
+	ap, err := missionAutopilot(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, ap.ResumeMission()
+}
+
+func (a *Admin) abortMission(msg messages.Message) (interface{}, error) {
+	ap, err := missionAutopilot(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, ap.AbortMission()
+}
+
+func (a *Admin) missionStatus(msg messages.Message) (interface{}, error) {
+	ap, err := missionAutopilot(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return ap.MissionProgress(), nil
+}
+
+// missionAutopilot returns the autopilot of the vehicle targeted by a mission command
+func missionAutopilot(msg messages.Message) (*autopilot.Autopilot, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need vehicleID")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	return autopilot.Lookup(vehicleID)
+}
+
+func (a *Admin) saveMissionPlan(msg messages.Message) (interface{}, error) {
+	plan, ok := msg.Data.(*models.MissionPlan)
+	if !ok {
+		return nil, errors.New("bad mission plan data format")
+	}
+
+	if plan.ID != "" {
+		if _, err := a.missionPlan(plan.ID, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	err := store.MissionPlans.Save(plan)
+	if errs, ok := err.(models.WaypointErrors); ok {
+		return libs.JSONObject{"errors": errs}, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func (a *Admin) validateMissionPlan(msg messages.Message) (interface{}, error) {
+	plan, ok := msg.Data.(*models.MissionPlan)
+	if !ok {
+		return nil, errors.New("bad mission plan data format")
+	}
+
+	errs := store.MissionPlans.Validate(plan)
+	return libs.JSONObject{
+		"valid":  len(errs) == 0,
+		"errors": errs,
+	}, nil
+}
+
+func (a *Admin) getMissionPlan(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need id")
+	}
+
+	id, ok := data.GetString("id")
+	if !ok {
+		return nil, errors.New("need id")
+	}
+
+	version, _ := data.GetNumber("version")
+
+	return a.missionPlan(id, int(version))
+}
+
+func (a *Admin) missionPlanVersions(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need id")
+	}
+
+	id, ok := data.GetString("id")
+	if !ok {
+		return nil, errors.New("need id")
+	}
+
+	if _, err := a.missionPlan(id, 0); err != nil {
+		return nil, err
+	}
+
+	return store.MissionPlans.Versions(id)
+}
+
+func (a *Admin) listMissionPlans(msg messages.Message) (interface{}, error) {
+	plans, err := store.MissionPlans.List()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []models.MissionPlan{}
+	for _, plan := range plans {
+		if plan.VehicleID == "" || a.admin.CanAccessVehicle(plan.VehicleID) {
+			list = append(list, plan)
+		}
+	}
+
+	return list, nil
+}
+
+func (a *Admin) deleteMissionPlan(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need id")
+	}
+
+	id, ok := data.GetString("id")
+	if !ok {
+		return nil, errors.New("need id")
+	}
+
+	if _, err := a.missionPlan(id, 0); err != nil {
+		return nil, err
+	}
+
+	return nil, store.MissionPlans.Delete(id)
+}
+
+// missionPlan returns a version of a stored plan
+// if the admin has access to its vehicle
+func (a *Admin) missionPlan(id string, version int) (*models.MissionPlan, error) {
+	plan := store.MissionPlans.Get(id, version)
+	if plan == nil {
+		return nil, fmt.Errorf("unknown mission plan with ID '%s'", id)
+	}
+
+	if plan.VehicleID != "" && !a.admin.CanAccessVehicle(plan.VehicleID) {
+		return nil, fmt.Errorf("no access to vehicle '%s'", plan.VehicleID)
+	}
+
+	return plan, nil
+}
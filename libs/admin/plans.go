@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/formats"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// importPlan converts a planning file to a mission, a fence and no fly
+// zones, nothing is saved: they are sent back to be reviewed and saved
+// with mission:save, fence:set and nofly:set
+func (a *Admin) importPlan(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need format and data")
+	}
+
+	format, ok := data.GetString("format")
+	if !ok {
+		return nil, errors.New("need format")
+	}
+
+	file, ok := data.GetString("data")
+	if !ok {
+		return nil, errors.New("need data")
+	}
+
+	plan, err := formats.Import(format, []byte(file))
+	if errs, ok := err.(formats.FeatureErrors); ok {
+		return libs.JSONObject{"errors": errs}, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.Mission != nil && plan.Mission.Name == "" {
+		plan.Mission.Name, _ = data.GetString("name")
+	}
+
+	return plan, nil
+}
+
+// exportMissionPlan exports a stored plan with
+// the fence of its vehicle and the no fly zones
+func (a *Admin) exportMissionPlan(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need id")
+	}
+
+	id, ok := data.GetString("id")
+	if !ok {
+		return nil, errors.New("need id")
+	}
+
+	version, _ := data.GetNumber("version")
+	plan, err := a.missionPlan(id, int(version))
+	if err != nil {
+		return nil, err
+	}
+
+	format, _ := data.GetString("format")
+	return exportPlan(format, plan.Name, plan.VehicleID, &plan.Mission)
+}
+
+func (a *Admin) exportFence(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("need vehicleID")
+	}
+
+	vehicleID, ok := data.GetString("vehicleID")
+	if !ok {
+		return nil, errors.New("need vehicleID")
+	}
+
+	if models.GetFence(vehicleID) == nil {
+		return nil, fmt.Errorf("no fence for vehicle '%s'", vehicleID)
+	}
+
+	format, _ := data.GetString("format")
+	return exportPlan(format, vehicleID+"-fence", vehicleID, nil)
+}
+
+// exportPlan encodes a mission with the fence of a vehicle and the no fly zones
+func exportPlan(format, name, vehicleID string, mission *models.Mission) (interface{}, error) {
+	if format == "" {
+		format = formats.FormatQGC
+	}
+
+	plan := formats.Plan{Mission: mission}
+	if fence := models.GetFence(vehicleID); fence != nil {
+		data := fence.JSON()
+		plan.Fence = &data
+	}
+	if noFly := models.GetNoFlyZones(); noFly != nil {
+		plan.NoFly = noFly.JSON()
+	}
+
+	out, err := formats.Export(format, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = "mission"
+	}
+
+	return libs.JSONObject{
+		"format":   format,
+		"filename": fmt.Sprintf("%s.%s", name, format),
+		"data":     string(out),
+	}, nil
+}
@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// maxReplayGap is the longest pause of a replay, longer
+// gaps between samples (vehicle off, ...) are skipped
+var maxReplayGap = time.Second * 5
+
+// replay re-publishes the recorded telemetry of a vehicle
+type replay struct {
+	vehicleID string
+	samples   []models.TelemetrySample
+	speed     float64
+	setSpeed  chan float64
+	done      libs.Done
+}
+
+func newReplay(vehicleID string, samples []models.TelemetrySample, speed float64) *replay {
+	return &replay{
+		vehicleID: vehicleID,
+		samples:   samples,
+		speed:     speed,
+		setSpeed:  make(chan float64),
+		done:      libs.NewDone(),
+	}
+}
+
+// run sends the samples with the same timing they were recorded
+// with, divided by the replay speed, until stopped or send fails
+func (r *replay) run(send func(messages.Message) error) {
+	defer r.done.Done()
+
+	telemetry := store.NewTelemetry()
+	telemetry.Replay = true
+
+	for i, sample := range r.samples {
+		if i > 0 {
+			gap := sample.Timestamp.Sub(r.samples[i-1].Timestamp)
+			if gap > maxReplayGap {
+				gap = maxReplayGap
+			}
+
+			if !r.wait(gap) {
+				return
+			}
+		}
+
+		if sample.Status != nil {
+			telemetry.Status = *sample.Status
+		}
+		if sample.Position != nil {
+			telemetry.Position = *sample.Position
+		}
+		if sample.Battery != nil {
+			telemetry.Battery = *sample.Battery
+		}
+		telemetry.Timestamp = sample.Timestamp
+
+		err := send(messages.New("telemetry", map[string]store.Telemetry{r.vehicleID: telemetry}))
+		if err != nil {
+			return
+		}
+	}
+}
+
+// wait waits for the recorded gap at the current speed,
+// returns false if the replay was stopped
+func (r *replay) wait(gap time.Duration) bool {
+	left := time.Duration(float64(gap) / r.speed)
+	for left > 0 {
+		start := time.Now()
+		timer := time.NewTimer(left)
+
+		select {
+		case <-timer.C:
+			return true
+		case speed := <-r.setSpeed:
+			timer.Stop()
+			left = time.Duration(float64(left-time.Since(start)) * r.speed / speed)
+			r.speed = speed
+		case <-r.done.WaitCh():
+			timer.Stop()
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetSpeed changes the speed of a running replay,
+// returns false if it is over
+func (r *replay) SetSpeed(speed float64) bool {
+	select {
+	case r.setSpeed <- speed:
+		return true
+	case <-r.done.WaitCh():
+		return false
+	}
+}
+
+// Stop stops the replay
+func (r *replay) Stop() {
+	r.done.Done()
+}
+
+// Done returns a channel closed when the replay is over
+func (r *replay) Done() <-chan bool {
+	return r.done.WaitCh()
+}
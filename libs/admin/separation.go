@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"errors"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/autopilot"
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+func (a *Admin) configSeparation(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("no data")
+	}
+
+	settings := store.Separation.Settings()
+	if horizontal, ok := data.GetNumber("horizontal"); ok {
+		settings.Horizontal = horizontal
+	}
+	if vertical, ok := data.GetNumber("vertical"); ok {
+		settings.Vertical = vertical
+	}
+	if horizon, ok := data.GetNumber("horizon"); ok {
+		settings.Horizon = horizon
+	}
+	if action, ok := data.GetString("action"); ok {
+		settings.Action = action
+	}
+
+	err := settings.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, store.Separation.SetSettings(settings)
+}
+
+// separationStatus returns the separation settings and the separation
+// between the flying vehicles the admin has access to
+func (a *Admin) separationStatus(msg messages.Message) (interface{}, error) {
+	separations := []models.Separation{}
+	for _, s := range autopilot.Separations() {
+		if a.admin.CanAccessVehicle(s.VehicleIDs[0]) || a.admin.CanAccessVehicle(s.VehicleIDs[1]) {
+			separations = append(separations, s)
+		}
+	}
+
+	return libs.JSONObject{
+		"settings":    store.Separation.Settings(),
+		"separations": separations,
+	}, nil
+}
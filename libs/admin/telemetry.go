@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+)
+
+// Bounds of the telemetry rate in updates per second
+var (
+	defaultTelemetryRate = 1.25
+	minTelemetryRate     = 0.1
+	maxTelemetryRate     = 20.0
+)
+
+// telemetrySubscription defines which vehicles' telemetry
+// an admin receives and how often
+type telemetrySubscription struct {
+	interval   time.Duration
+	vehicleIDs []string
+}
+
+func newTelemetrySubscription() telemetrySubscription {
+	return telemetrySubscription{
+		interval: rateInterval(defaultTelemetryRate),
+	}
+}
+
+func rateInterval(rate float64) time.Duration {
+	return time.Duration(float64(time.Second) / rate)
+}
+
+// sendTelemetry sends the telemetry of the subscribed
+// vehicles that changed since the last send
+func (a *Admin) sendTelemetry() {
+	now := time.Now()
+	telemetry := store.Vehicles.TelemetryJSON(a.lastSentTelemetry, a.telemetry.vehicleIDs)
+
+	// Live telemetry of a replayed vehicle would mix with the replay
+	var replayed string
+	if r := a.getReplay(); r != nil {
+		replayed = r.vehicleID
+	}
+
+	for vehicleID := range telemetry {
+		if !a.admin.CanAccessVehicle(vehicleID) || vehicleID == replayed {
+			delete(telemetry, vehicleID)
+		}
+	}
+
+	if len(telemetry) == 0 {
+		a.lastSentTelemetry = now
+		return
+	}
+
+	err := a.ch.Send(messages.New("telemetry", telemetry))
+	if err != nil {
+		log.Println(err)
+	} else {
+		a.lastSentTelemetry = now
+	}
+}
+
+func (a *Admin) subscribeTelemetry(msg messages.Message) (interface{}, error) {
+	data := msg.JSONData()
+	if data == nil {
+		return nil, errors.New("no data")
+	}
+
+	sub := newTelemetrySubscription()
+
+	if rate, ok := data.GetNumber("rate"); ok {
+		if rate < minTelemetryRate || rate > maxTelemetryRate {
+			return nil, fmt.Errorf("rate should be between %v and %v updates per second", minTelemetryRate, maxTelemetryRate)
+		}
+		sub.interval = rateInterval(rate)
+	}
+
+	vehicleIDs, err := getStrings(data, "vehicleIDs")
+	if err != nil {
+		return nil, err
+	}
+	for _, vehicleID := range vehicleIDs {
+		if !a.admin.CanAccessVehicle(vehicleID) {
+			return nil, fmt.Errorf("no access to vehicle '%s'", vehicleID)
+		}
+	}
+	sub.vehicleIDs = vehicleIDs
+
+	select {
+	case a.telemetrySubs <- sub:
+	case <-a.ch.Done():
+		return nil, errors.New("disconnected")
+	}
+
+	return nil, nil
+}
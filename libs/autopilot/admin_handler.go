@@ -13,6 +13,10 @@ func (ap *Autopilot) handleAdminMessage(msg messages.Message) {
 		ap.onEnableFence(msg)
 	case "fence:disable":
 		ap.onDisableFence(msg)
+	case "fence:update":
+		ap.onUpdateFence(msg)
+	case "vehicle:disconnected":
+		ap.endFlight()
 	case "stop":
 		ap.onStop()
 	}
@@ -28,16 +32,20 @@ func (ap *Autopilot) onDisableFence(msg messages.Message) {
 	msg.Reply(nil, nil)
 }
 
+func (ap *Autopilot) onUpdateFence(msg messages.Message) {
+	var err error
+	if ap.getFence() != nil {
+		err = ap.enableFence()
+	}
+	msg.Reply(nil, err)
+}
+
 func (ap *Autopilot) onStop() {
 	ap.done.Done()
 }
 
 func (ap *Autopilot) enableFence() error {
-	if ap.getFence() != nil {
-		return nil
-	}
-
-	fence := models.GetFence()
+	fence := models.GetFence(ap.vehicleID)
 	if fence == nil {
 		return errors.New("No fence set")
 	}
@@ -48,6 +56,9 @@ func (ap *Autopilot) enableFence() error {
 }
 
 func (ap *Autopilot) setFence(fence *models.Fence) {
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+
 	if ap.fence != nil {
 		ap.fence.Done()
 		ap.fence = nil
@@ -61,5 +72,8 @@ func (ap *Autopilot) setFence(fence *models.Fence) {
 }
 
 func (ap *Autopilot) getFence() *fenceHandler {
+	ap.lock.RLock()
+	defer ap.lock.RUnlock()
+
 	return ap.fence
 }
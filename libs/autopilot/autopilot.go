@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/store"
 	"github.com/volons/hive/messages"
 	"github.com/volons/hive/models"
 )
@@ -17,22 +18,38 @@ var autopilots sync.Map
 type Autopilot struct {
 	//*dispatcher.Dispatcher
 
-	vehicle *messages.Line
-	user    *messages.Line
-	admin   *messages.Line
+	vehicle  *messages.Line
+	admin    *messages.Line
+	users    map[string]*userConn // not thread safe, use lock
+	userMsgs chan userMessage
 
 	vehicleID    string           // thread safe, set once at creation
 	overridingRc *libs.AtomicBool // thread safe, atomic, set at creation
 
-	fence *fenceHandler // not thread safe, use lock
-	pilot string        // not thread safe, use lock
+	fence    *fenceHandler  // not thread safe, use lock
+	pilot    string         // not thread safe, use lock
+	pilotAt  time.Time      // not thread safe, use lock
+	session  *Session       // not thread safe, use lock
+	flight   *models.Flight // only used by the run loop
+	caps     models.Caps    // not thread safe, use lock
+	mission  *missionRunner // not thread safe, use lock
+	takeover string         // not thread safe, use lock
+
+	batteryLevel   models.BatteryLevel  // only used by the run loop
+	failsafe       models.FailsafeState // only used by the run loop
+	lastPilotInput time.Time            // only used by the run loop
+	lastTelemetry  time.Time            // only used by the run loop
+	flightSavedAt  time.Time            // only used by the run loop
+
+	heading    float64          // only used by the run loop
+	separation *models.RcLimits // not thread safe, use lock
 
 	autoRc   *models.Rc // not thread safe, use lock
 	manualRc *models.Rc // thread safe, set once at creation
 	nullRc   *models.Rc // thread safe, set once at creation
 	rcTicker chan bool
 
-	//lock *sync.RWMutex
+	lock *sync.RWMutex
 	done libs.Done
 }
 
@@ -49,34 +66,67 @@ func Get(vehicleID string) *Autopilot {
 	// if new init autopilot
 	//ap.Dispatcher = dispatcher.Get(vehicleID)
 	ap.vehicle = messages.NewLine(fmt.Sprintf("autopilot:%v:vehicle", vehicleID), false)
-	ap.user = messages.NewLine(fmt.Sprintf("autopilot:%v:user", vehicleID), false)
 	ap.admin = messages.NewLine(fmt.Sprintf("autopilot:%v:admin", vehicleID), false)
 	ap.manualRc = models.NewNullRc()
 	ap.nullRc = models.NewNullRc()
 	ap.overridingRc = &libs.AtomicBool{}
 	ap.vehicleID = vehicleID
-	//ap.lock = &sync.RWMutex{}
+	ap.users = make(map[string]*userConn)
+	ap.userMsgs = make(chan userMessage)
+	ap.rcTicker = make(chan bool)
+	ap.failsafe = models.FailsafeNone
+	ap.lock = &sync.RWMutex{}
+	ap.done = libs.NewDone()
 
 	go ap.run()
 
 	return ap
 }
 
+// Lookup returns the autopilot of a known vehicle, unlike Get
+// it does not start autopilots for unknown vehicle IDs
+func Lookup(vehicleID string) (*Autopilot, error) {
+	if ap := running(vehicleID); ap != nil {
+		return ap, nil
+	}
+
+	if !store.Vehicles.Known(vehicleID) {
+		return nil, fmt.Errorf("unknown vehicle with ID '%s'", vehicleID)
+	}
+
+	return Get(vehicleID), nil
+}
+
+// running returns the autopilot of a vehicle if it was started
+func running(vehicleID string) *Autopilot {
+	val, ok := autopilots.Load(key(vehicleID))
+	if !ok {
+		return nil
+	}
+
+	return val.(*Autopilot)
+}
+
 func (ap *Autopilot) run() {
+	failsafeTicker := time.NewTicker(failsafeInterval)
+	defer failsafeTicker.Stop()
+
 	for {
 		select {
 		case msg := <-ap.vehicle.Recv():
 			libs.TMP = "handling vehicle message"
 			ap.handleVehicleMessage(msg)
 			libs.TMP = "handled vehicle message"
-		case msg := <-ap.user.Recv():
+		case um := <-ap.userMsgs:
 			libs.TMP = "handling user message"
-			ap.handleUserMessage(msg)
+			ap.handleUserMessage(um.userID, um.msg)
 			libs.TMP = "handled user message"
 		case msg := <-ap.admin.Recv():
 			libs.TMP = "handling admin message"
 			ap.handleAdminMessage(msg)
 			libs.TMP = "handled admin message"
+		case <-failsafeTicker.C:
+			ap.checkFailsafe()
 		case <-ap.rcTicker:
 			libs.TMP = "sending rc"
 			ap.vehicle.Send(messages.New("rc", ap.GetRc()))
@@ -91,8 +141,13 @@ func (ap *Autopilot) run() {
 
 func (ap *Autopilot) stop() {
 	ap.vehicle.Close()
-	ap.user.Close()
 	ap.admin.Close()
+
+	ap.lock.RLock()
+	for _, conn := range ap.users {
+		conn.line.Disconnect()
+	}
+	ap.lock.RUnlock()
 }
 
 // ConnectVehicle should be called by the vehicle of the same ID to listen to events
@@ -100,11 +155,6 @@ func (ap *Autopilot) ConnectVehicle(vehicle *messages.Line) {
 	ap.vehicle.Connect(vehicle)
 }
 
-// ConnectUser should be called by the user that wishes to listen to this vehicle
-func (ap *Autopilot) ConnectUser(user *messages.Line) {
-	ap.user.Connect(user)
-}
-
 func (ap *Autopilot) Push(msg messages.Message) error {
 	return ap.admin.Push(msg)
 }
@@ -119,8 +169,22 @@ func (ap *Autopilot) SetAutoRc(autoRc *models.Rc) {
 	ap.autoRc = autoRc
 }
 
-// GetRc returns the appropriate rc
+// GetRc returns the appropriate rc, limited to keep
+// the separation with other vehicles
 func (ap *Autopilot) GetRc() *models.Rc {
+	rc := ap.selectRc()
+
+	if limits := ap.separationLimits(); limits != nil {
+		rc = rc.Copy()
+		rc.Rotate(-ap.heading)
+		rc.ApplyLimits(*limits)
+		rc.Rotate(ap.heading)
+	}
+
+	return rc
+}
+
+func (ap *Autopilot) selectRc() *models.Rc {
 	autoRc := ap.AutoRc()
 	if autoRc != nil {
 		return autoRc
@@ -135,37 +199,3 @@ func (ap *Autopilot) GetRc() *models.Rc {
 func (ap *Autopilot) Done() <-chan bool {
 	return ap.done.WaitCh()
 }
-
-// StartOrResumeSession starts a user session
-//func (ap *Autopilot) StartOrResumeSession(userID string) (chan bool, error) {
-//	session := StartSession(ap.vehicleID, userID)
-//	ap.user.Send(messages.New("countdown", libs.JSONObject{
-//		"time": session.TimeLeft(),
-//	}))
-//	return session.done, nil
-//}
-
-// GetPilot gets the the current pilot userID
-//func (ap *Autopilot) GetPilot() string {
-//	ap.lock.Lock()
-//	defer ap.lock.Unlock()
-//	return ap.pilot
-//}
-
-// SetPilot sets userID as the current pilot
-//func (ap *Autopilot) SetPilot(userID string) {
-//	ap.lock.Lock()
-//	ap.pilot = userID
-//	ap.lock.Unlock()
-//	ap.user.Send(messages.New("pilot", libs.JSONObject{
-//		"userID": userID,
-//	}))
-//}
-
-// HasControl checks if the provided user has control
-// over this vehicle
-//func (ap *Autopilot) HasControl(userID string) bool {
-//	ap.lock.RLock()
-//	defer ap.lock.RUnlock()
-//	return ap.pilot == userID
-//}
@@ -0,0 +1,65 @@
+package autopilot
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/volons/hive/libs/db"
+	"github.com/volons/hive/messages"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "hive-autopilot")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = db.Init(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// tests check the failsafe themselves
+	failsafeInterval = time.Hour
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestAutopilot starts the autopilot of a new vehicle connected
+// to the returned line, stopped at the end of the test
+func newTestAutopilot(t *testing.T) (*Autopilot, *messages.Line) {
+	// stopped autopilots are not removed, every test needs its own vehicle
+	vehicleID := fmt.Sprintf("%v-%d", t.Name(), time.Now().UnixNano())
+
+	ap := Get(vehicleID)
+	t.Cleanup(ap.onStop)
+
+	vehicle := messages.NewLine(vehicleID, false)
+	ap.ConnectVehicle(vehicle)
+
+	return ap, vehicle
+}
+
+// nextMessage returns the next message of the given type sent to the
+// vehicle, requests are accepted
+func nextMessage(t *testing.T, vehicle *messages.Line, typ string) messages.Message {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case msg := <-vehicle.Recv():
+			if msg.IsRequest() {
+				msg.Reply(nil, nil)
+			}
+			if msg.Type == typ {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("no %v sent to the vehicle", typ)
+		}
+	}
+}
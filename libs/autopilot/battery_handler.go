@@ -0,0 +1,99 @@
+package autopilot
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// checkBattery updates the battery level of the vehicle and,
+// while it flies, forces it back or down when the level gets critical
+func (ap *Autopilot) checkBattery(batt models.Battery) {
+	if batt.Percent < 0 {
+		return
+	}
+
+	settings := store.Vehicles.Settings(ap.vehicleID).Battery
+	level := settings.Level(batt.Percent, ap.batteryLevel)
+	if level == ap.batteryLevel {
+		return
+	}
+
+	escalated := level > ap.batteryLevel
+	ap.batteryLevel = level
+
+	message := fmt.Sprintf("battery level %v (%.0f%%)", level, batt.Percent)
+	ap.sendToUsers(messages.New("battery:level", libs.JSONObject{
+		"level":   level,
+		"percent": batt.Percent,
+		"message": message,
+	}))
+
+	severity := models.EventInfo
+	if level == models.BatteryWarning {
+		severity = models.EventWarning
+	} else if level > models.BatteryWarning {
+		severity = models.EventCritical
+	}
+	go store.Events.Add(models.NewEvent("battery", ap.vehicleID, severity, message, libs.JSONObject{
+		"level":   level,
+		"battery": batt,
+	}))
+
+	// Actions are never undone when the battery recovers
+	if !escalated || ap.flight == nil {
+		return
+	}
+
+	ap.batteryTakeOver(message)
+}
+
+// batteryTakeOver forces the vehicle back or down if its battery
+// level is critical, also called when a flight starts since the
+// level may have been reached on the ground or before a restart
+func (ap *Autopilot) batteryTakeOver(reason string) {
+	switch ap.batteryLevel {
+	case models.BatteryCritical:
+		ap.takeOver("rtl", reason)
+	case models.BatteryEmergency:
+		ap.takeOver("land", reason)
+	}
+}
+
+// takeOver takes the control away from the pilot
+// and sends a command such as rtl or land to the vehicle
+func (ap *Autopilot) takeOver(command string, reason string) {
+	log.Printf("Autopilot of vehicle '%v' forces %v: %v\n", ap.vehicleID, command, reason)
+
+	if r := ap.getMission(); r != nil {
+		r.Abort(false)
+	}
+
+	// Keep users from taking control back until the vehicle lands
+	ap.lock.Lock()
+	ap.takeover = command
+	ap.lock.Unlock()
+
+	ap.stopSession()
+
+	ap.SetPilot("")
+	ap.StopRcOverride()
+
+	ap.sendToUsers(messages.New("takeover", libs.JSONObject{
+		"command": command,
+		"reason":  reason,
+	}))
+
+	go func() {
+		err := ap.Command(command)
+		if err != nil {
+			log.Printf("Vehicle '%v' could not %v: %v\n", ap.vehicleID, command, err)
+			store.Events.Add(models.NewEvent("takeover", ap.vehicleID, models.EventCritical,
+				fmt.Sprintf("could not %v: %v", command, err), nil))
+		}
+	}()
+}
@@ -0,0 +1,46 @@
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/volons/hive/models"
+)
+
+func TestBatteryCriticalForcesRTL(t *testing.T) {
+	ap, vehicle := newTestAutopilot(t)
+	ap.flight = models.NewFlight(ap.vehicleID, "")
+	ap.SetPilot("pilot")
+
+	ap.checkBattery(models.NewBattery(0, 0, 15))
+	nextMessage(t, vehicle, "rtl")
+
+	if pilot := ap.GetPilot(); pilot != "" {
+		t.Errorf("pilot '%v' kept control after the takeover", pilot)
+	}
+	ap.lock.RLock()
+	if ap.controlLocked() == nil {
+		t.Error("control can be claimed back before the end of the flight")
+	}
+	ap.lock.RUnlock()
+
+	ap.endFlight()
+	ap.lock.RLock()
+	if ap.controlLocked() != nil {
+		t.Error("control still locked once the flight ended")
+	}
+	ap.lock.RUnlock()
+}
+
+func TestBatteryCriticalAtFlightStart(t *testing.T) {
+	ap, vehicle := newTestAutopilot(t)
+
+	// nothing is forced on the ground
+	ap.checkBattery(models.NewBattery(0, 0, 5))
+	if ap.batteryLevel != models.BatteryEmergency {
+		t.Fatalf("battery level %v at 5%%, should be emergency", ap.batteryLevel)
+	}
+
+	ap.trackFlight(true)
+	nextMessage(t, vehicle, "land")
+	ap.endFlight()
+}
@@ -0,0 +1,71 @@
+package autopilot
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// capMessages lists the messages that need the vehicle to declare
+// the matching capability, by message type prefix
+var capMessages = map[string]bool{
+	"goto":   true,
+	"gimbal": true,
+	"webrtc": true,
+}
+
+// Caps returns the capabilities declared by the vehicle
+func (ap *Autopilot) Caps() models.Caps {
+	ap.lock.RLock()
+	defer ap.lock.RUnlock()
+
+	return ap.caps
+}
+
+// SetCaps sets the capabilities declared by the vehicle
+func (ap *Autopilot) SetCaps(caps models.Caps) {
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+
+	ap.caps = caps
+}
+
+// Supports checks if the vehicle can handle a message type, vehicles
+// that never declared their capabilities are assumed to support everything
+func (ap *Autopilot) Supports(msgType string) bool {
+	feature := messages.New(msgType, nil).SubType(0)
+	if !capMessages[feature] {
+		return true
+	}
+
+	caps := ap.Caps()
+	return caps == nil || caps.Supports(feature)
+}
+
+// checkSupport returns an error if the vehicle cannot handle a message type
+func (ap *Autopilot) checkSupport(msgType string) error {
+	if !ap.Supports(msgType) {
+		return fmt.Errorf("vehicle does not support '%v'", msgType)
+	}
+
+	return nil
+}
+
+func (ap *Autopilot) onCapsMessage(msg messages.Message) {
+	caps, ok := msg.Data.(*models.Caps)
+	if !ok {
+		return
+	}
+
+	ap.SetCaps(*caps)
+
+	err := store.Vehicles.SetCaps(ap.vehicleID, *caps)
+	if err != nil {
+		log.Println(err)
+	}
+
+	ap.forwardToUser(msg)
+}
@@ -0,0 +1,122 @@
+package autopilot
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// failsafeInterval is the period at which links are checked
+var failsafeInterval = time.Millisecond * 500
+
+// checkFailsafe escalates from hold to rtl when the pilot stops sending
+// input and lands the vehicle when its telemetry goes stale, only while it flies
+func (ap *Autopilot) checkFailsafe() {
+	if ap.flight == nil {
+		ap.setFailsafe(models.FailsafeNone, "vehicle is not flying")
+		return
+	}
+
+	// rtl and land are kept until the end of the flight
+	if ap.failsafe == models.FailsafeRTL || ap.failsafe == models.FailsafeLand {
+		return
+	}
+
+	settings := store.Vehicles.Settings(ap.vehicleID).Failsafe
+
+	lastTelemetry := ap.lastTelemetry
+	if ap.flight.Start.After(lastTelemetry) {
+		lastTelemetry = ap.flight.Start
+	}
+
+	if since := time.Since(lastTelemetry); since > settings.TelemetryTime() {
+		reason := fmt.Sprintf("no telemetry for %.0f s", since.Seconds())
+		ap.setFailsafe(models.FailsafeLand, reason)
+		ap.takeOver("land", reason)
+		return
+	}
+
+	pilot, pilotAt := ap.getPilotSince()
+	if pilot == "" {
+		ap.setFailsafe(models.FailsafeNone, "no pilot")
+		return
+	}
+
+	lastInput := ap.lastPilotInput
+	if pilotAt.After(lastInput) {
+		lastInput = pilotAt
+	}
+
+	since := time.Since(lastInput)
+	reason := fmt.Sprintf("no pilot input for %.0f s", since.Seconds())
+	switch {
+	case since > settings.RTLTime():
+		ap.setFailsafe(models.FailsafeRTL, reason)
+		ap.takeOver("rtl", reason)
+	case since > settings.HoldTime():
+		if ap.setFailsafe(models.FailsafeHold, reason) {
+			ap.holdPosition()
+		}
+	default:
+		ap.setFailsafe(models.FailsafeNone, "pilot input received")
+	}
+}
+
+// holdPosition sends the vehicle to its last known position
+func (ap *Autopilot) holdPosition() {
+	pos := store.Vehicles.Position(ap.vehicleID)
+	if pos == nil {
+		log.Printf("Vehicle '%v' could not hold, its position is unknown\n", ap.vehicleID)
+		return
+	}
+
+	go func() {
+		err := ap.GoTo(*pos)
+		if err != nil {
+			log.Printf("Vehicle '%v' could not hold: %v\n", ap.vehicleID, err)
+		}
+	}()
+}
+
+// setFailsafe changes the failsafe state and reports it to
+// the users and admins, returns true if the state changed
+func (ap *Autopilot) setFailsafe(state models.FailsafeState, reason string) bool {
+	if ap.failsafe == state {
+		return false
+	}
+
+	prev := ap.failsafe
+	ap.failsafe = state
+
+	ap.sendToUsers(messages.New("failsafe", libs.JSONObject{
+		"state":  state,
+		"reason": reason,
+	}))
+
+	severity := models.EventCritical
+	if state == models.FailsafeNone {
+		severity = models.EventInfo
+	} else if state == models.FailsafeHold {
+		severity = models.EventWarning
+	}
+	go store.Events.Add(models.NewEvent("failsafe", ap.vehicleID, severity,
+		fmt.Sprintf("failsafe %v: %v", state, reason), libs.JSONObject{
+			"state":    state,
+			"previous": prev,
+		}))
+
+	return true
+}
+
+// getPilotSince returns the current pilot and when it got control
+func (ap *Autopilot) getPilotSince() (string, time.Time) {
+	ap.lock.RLock()
+	defer ap.lock.RUnlock()
+
+	return ap.pilot, ap.pilotAt
+}
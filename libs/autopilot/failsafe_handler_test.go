@@ -0,0 +1,70 @@
+package autopilot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/models"
+)
+
+// flyWithPilot starts a flight with a pilot whose last input was ago
+func flyWithPilot(ap *Autopilot, ago time.Duration) {
+	ap.flight = models.NewFlight(ap.vehicleID, "pilot")
+	ap.lastTelemetry = time.Now()
+
+	ap.lock.Lock()
+	ap.pilot = "pilot"
+	ap.pilotAt = time.Now().Add(-ago)
+	ap.lock.Unlock()
+}
+
+func TestFailsafeHoldsThenReturns(t *testing.T) {
+	ap, vehicle := newTestAutopilot(t)
+	defer ap.endFlight()
+
+	pos := models.NewPoint(48.85, 2.35, 20)
+	store.Vehicles.SetPosition(ap.vehicleID, &pos)
+
+	flyWithPilot(ap, 5*time.Second)
+	ap.checkFailsafe()
+	if ap.failsafe != models.FailsafeHold {
+		t.Fatalf("failsafe %v after 5s without input, should be hold", ap.failsafe)
+	}
+
+	msg := nextMessage(t, vehicle, "goto")
+	if target, ok := msg.Data.(models.Target); !ok || target.Lat != pos.Lat || target.Lon != pos.Lon || target.RelAlt != pos.RelAlt {
+		t.Errorf("vehicle sent to %v to hold instead of its last position", msg.Data)
+	}
+
+	ap.lock.Lock()
+	ap.pilotAt = time.Now().Add(-time.Minute)
+	ap.lock.Unlock()
+	ap.checkFailsafe()
+	if ap.failsafe != models.FailsafeRTL {
+		t.Fatalf("failsafe %v after a minute without input, should be rtl", ap.failsafe)
+	}
+	nextMessage(t, vehicle, "rtl")
+
+	// rtl is kept until the end of the flight
+	ap.lastPilotInput = time.Now()
+	ap.checkFailsafe()
+	if ap.failsafe != models.FailsafeRTL {
+		t.Errorf("failsafe %v once the pilot input is back, should stay rtl", ap.failsafe)
+	}
+}
+
+func TestFailsafeLandsWithoutTelemetry(t *testing.T) {
+	ap, vehicle := newTestAutopilot(t)
+	defer ap.endFlight()
+
+	flyWithPilot(ap, 0)
+	ap.flight.Start = time.Now().Add(-time.Minute)
+	ap.lastTelemetry = time.Now().Add(-10 * time.Second)
+
+	ap.checkFailsafe()
+	if ap.failsafe != models.FailsafeLand {
+		t.Fatalf("failsafe %v after 10s without telemetry, should be land", ap.failsafe)
+	}
+	nextMessage(t, vehicle, "land")
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/volons/hive/libs"
 	"github.com/volons/hive/libs/callback"
+	"github.com/volons/hive/libs/store"
 	"github.com/volons/hive/messages"
 	"github.com/volons/hive/models"
 )
@@ -57,15 +58,23 @@ func (h *fenceHandler) checkFence(pos models.Position) {
 	}
 
 	autoRc, targetPos, slowed, outside := h.fence.GetAutoRc(pos, h.ap.manualRc)
+
+	// No fly zones behave like walls inside the fence
+	if noFly := models.GetNoFlyZones(); noFly != nil && !outside {
+		var noFlySlowed bool
+		autoRc, targetPos, noFlySlowed, outside = noFly.GetAutoRc(pos, autoRc, h.fence)
+		slowed = slowed || noFlySlowed
+	}
+
 	h.ap.SetAutoRc(autoRc)
-	h.setFenceStatus(slowed, outside)
+	h.setFenceStatus(pos, autoRc, slowed, outside)
 
 	if targetPos != nil {
 		h.goTo(*targetPos)
 	}
 }
 
-func (h *fenceHandler) setFenceStatus(slowed bool, outside bool) {
+func (h *fenceHandler) setFenceStatus(pos models.Position, autoRc *models.Rc, slowed bool, outside bool) {
 	var changed bool
 
 	if h.state.Slowed != slowed {
@@ -79,7 +88,17 @@ func (h *fenceHandler) setFenceStatus(slowed bool, outside bool) {
 	}
 
 	if changed {
-		h.ap.user.Send(messages.New("fence_state", h.state))
+		h.ap.sendToUsers(messages.New("fence_state", h.state))
+
+		event := models.NewFenceEvent(h.ap.vehicleID, h.ap.GetPilot(), h.state, pos, h.ap.manualRc, autoRc)
+		go store.FenceEvents.Add(event)
+
+		// The autopilot overrides the pilot's rc while
+		// the vehicle is slowed or pushed back by the fence
+		go store.Audit.Add(models.NewAuditEntry(models.AuditSourceAutopilot, "", h.ap.vehicleID, "rc", libs.JSONObject{
+			"state":  h.state,
+			"autoRc": autoRc.Data(),
+		}))
 	}
 }
 
@@ -94,12 +113,21 @@ func (h *fenceHandler) goTo(pos models.Position) {
 
 // GoTo moves the vehicle to the specified position
 func (ap *Autopilot) GoTo(pos models.Position) error {
+	return ap.GoToTarget(models.Target{Position: pos})
+}
+
+// GoToTarget moves the vehicle to the specified target
+func (ap *Autopilot) GoToTarget(target models.Target) error {
 	if !ap.vehicle.Connected() {
 		return fmt.Errorf("Vehicle not connected")
 	}
+	if err := ap.checkSupport("goto"); err != nil {
+		return err
+	}
 
 	cb := callback.New()
-	ap.vehicle.Send(messages.NewRequest("goto", pos, cb))
+	store.Audit.Record(models.NewAuditEntry(models.AuditSourceAutopilot, "", ap.vehicleID, "goto", target), cb)
+	ap.vehicle.Send(messages.NewRequest("goto", target, cb))
 	_, err := cb.Timeout(time.Minute).Wait()
 
 	return err
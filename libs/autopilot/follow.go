@@ -0,0 +1,145 @@
+package autopilot
+
+import (
+	"errors"
+	"time"
+
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// followMinMove is the distance in meters the follow target
+// has to move before a new goto is sent to the vehicle
+var followMinMove = float64(2)
+
+func newFollowRunner(ap *Autopilot, follow models.Follow) *missionRunner {
+	r := newMissionRunner(ap, models.Mission{Name: "follow"})
+	r.follow = &follow
+	r.targets = make(chan models.Position, 1)
+
+	return r
+}
+
+// runFollow sends the vehicle after every new target
+// until the follow is aborted
+func (r *missionRunner) runFollow() {
+	r.setProgress(models.MissionRunning, 0, "waiting for a target")
+
+	var target *models.Target
+	for {
+		select {
+		case pos := <-r.targets:
+			next := r.follow.Target(r.position(), pos)
+			if target != nil && next.Reached(target.Position, followMinMove, followMinMove) {
+				continue
+			}
+			if !r.followTo(next) {
+				return
+			}
+			target = &next
+		case <-r.positions:
+		case <-r.pause:
+			if !r.paused() || (target != nil && !r.followTo(*target)) {
+				return
+			}
+		case <-r.abort.WaitCh():
+			r.aborted()
+			return
+		}
+	}
+}
+
+// followTo sends the vehicle to a target if it and the way to it
+// stay inside the fence and out of the no fly zones, otherwise the
+// vehicle keeps its previous target, returns false if the goto failed
+func (r *missionRunner) followTo(target models.Target) bool {
+	wp := models.Waypoint{Lat: target.Lat, Lon: target.Lon, RelAlt: target.RelAlt}
+	route := models.Mission{Waypoints: []models.Waypoint{wp}}
+	if errs := route.CheckZones(r.position(), r.ap.activeFence(), models.GetNoFlyZones()); len(errs) > 0 {
+		if msg := "target rejected: " + errs[0].Message; r.Progress().Message != msg {
+			r.setState(models.MissionRunning, msg)
+		}
+		return true
+	}
+
+	if r.Progress().Message != "" {
+		r.setState(models.MissionRunning, "")
+	}
+
+	return r.goTo(target)
+}
+
+// position returns the last known position of the vehicle
+func (r *missionRunner) position() *models.Position {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.lastPos
+}
+
+// onTarget feeds the runner with the follow target, without blocking
+func (r *missionRunner) onTarget(pos models.Position) {
+	select {
+	case <-r.targets:
+	default:
+	}
+
+	select {
+	case r.targets <- pos:
+	default:
+	}
+}
+
+// StartFollow takes the control from the pilot and keeps the vehicle
+// at a distance of the target set with SetFollowTarget
+func (ap *Autopilot) StartFollow(follow models.Follow) error {
+	if err := follow.Validate(); err != nil {
+		return err
+	}
+
+	if !ap.vehicle.Connected() {
+		return errors.New("Vehicle not connected")
+	}
+	if err := ap.checkSupport("goto"); err != nil {
+		return err
+	}
+
+	r := newFollowRunner(ap, follow)
+	if pos := store.Vehicles.Position(ap.vehicleID); pos != nil {
+		r.lastPos = pos
+	}
+
+	return ap.runMission(r)
+}
+
+// SetFollowTarget moves the target the vehicle follows
+func (ap *Autopilot) SetFollowTarget(pos models.Position) error {
+	r := ap.getMission()
+	if r == nil || r.follow == nil {
+		return errors.New("Vehicle is not following a target")
+	}
+
+	r.onTarget(pos)
+
+	return nil
+}
+
+// onFollowTarget sets the follow target sent by a user
+func (ap *Autopilot) onFollowTarget(userID string, msg messages.Message) {
+	pos, ok := msg.Data.(*models.Position)
+	if !ok {
+		ap.deny(userID, msg, "format", "bad follow target format")
+		return
+	}
+
+	err := ap.SetFollowTarget(*pos)
+	if err == nil {
+		// the pilot cannot send rc while following, its targets
+		// keep the link loss failsafe from triggering
+		ap.lastPilotInput = time.Now()
+	}
+	if msg.IsRequest() {
+		msg.Reply(nil, err)
+	}
+}
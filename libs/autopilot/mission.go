@@ -0,0 +1,421 @@
+package autopilot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/callback"
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// Distances in meters within which a waypoint is reached
+var (
+	arrivalRadius = float64(2)
+	arrivalHeight = float64(1.5)
+)
+
+// missionRunner drives a vehicle through the waypoints of a mission,
+// or after a moving target when follow is set
+type missionRunner struct {
+	ap        *Autopilot
+	mission   models.Mission
+	follow    *models.Follow
+	targets   chan models.Position
+	positions chan models.Position
+	pause     chan bool
+	abort     libs.Done
+	done      libs.Done
+
+	lock     sync.RWMutex
+	lastPos  *models.Position
+	progress models.MissionProgress
+	hold     bool // hold the position once aborted
+}
+
+func newMissionRunner(ap *Autopilot, mission models.Mission) *missionRunner {
+	return &missionRunner{
+		ap:        ap,
+		mission:   mission,
+		positions: make(chan models.Position, 1),
+		pause:     make(chan bool),
+		abort:     libs.NewDone(),
+		done:      libs.NewDone(),
+		progress: models.MissionProgress{
+			Name:  mission.Name,
+			State: models.MissionRunning,
+			Total: len(mission.Waypoints),
+		},
+	}
+}
+
+// run flies to every waypoint in order
+func (r *missionRunner) run() {
+	defer r.done.Done()
+
+	if r.follow != nil {
+		r.runFollow()
+		return
+	}
+
+	for i, wp := range r.mission.Waypoints {
+		r.setProgress(models.MissionRunning, i, "")
+
+		if !r.fly(wp) || !r.atWaypoint(wp) {
+			return
+		}
+	}
+
+	r.setProgress(models.MissionCompleted, len(r.mission.Waypoints), "")
+}
+
+// fly sends the vehicle to a waypoint and waits for its arrival,
+// returns false if the mission was aborted or failed
+func (r *missionRunner) fly(wp models.Waypoint) bool {
+	target := wp.Target()
+	if !r.goTo(target) {
+		return false
+	}
+
+	for {
+		select {
+		case pos := <-r.positions:
+			if target.Reached(pos, arrivalRadius, arrivalHeight) {
+				return true
+			}
+		case <-r.pause:
+			if !r.paused() || !r.goTo(target) {
+				return false
+			}
+		case <-r.abort.WaitCh():
+			r.aborted()
+			return false
+		}
+	}
+}
+
+// atWaypoint runs the waypoint's gimbal action and holds,
+// returns false if the mission was aborted or failed
+func (r *missionRunner) atWaypoint(wp models.Waypoint) bool {
+	if wp.Gimbal != nil {
+		err := r.ap.SetGimbal(*wp.Gimbal)
+		if err != nil {
+			log.Printf("Mission of vehicle '%v' could not set gimbal: %v\n", r.ap.vehicleID, err)
+		}
+	}
+
+	left := time.Duration(wp.Hold * float64(time.Second))
+	for left > 0 {
+		start := time.Now()
+		timer := time.NewTimer(left)
+
+		select {
+		case <-timer.C:
+			return true
+		case <-r.pause:
+			timer.Stop()
+			left -= time.Since(start)
+			if !r.paused() {
+				return false
+			}
+		case <-r.abort.WaitCh():
+			timer.Stop()
+			r.aborted()
+			return false
+		}
+	}
+
+	return true
+}
+
+// paused holds the vehicle in place until the mission is resumed,
+// returns false if it is aborted or the vehicle could not hold
+func (r *missionRunner) paused() bool {
+	r.setState(models.MissionPaused, "")
+
+	r.lock.RLock()
+	lastPos := r.lastPos
+	r.lock.RUnlock()
+
+	var held <-chan error
+	if lastPos != nil {
+		held = r.sendGoTo(models.Target{Position: *lastPos})
+	}
+
+	for {
+		select {
+		case err := <-held:
+			if err != nil {
+				r.setState(models.MissionFailed, fmt.Sprintf("goto failed: %v", err))
+				return false
+			}
+			held = nil
+		case <-r.pause:
+			r.setState(models.MissionRunning, "")
+			return true
+		case <-r.abort.WaitCh():
+			r.aborted()
+			return false
+		}
+	}
+}
+
+// aborted stops the vehicle where it is unless the
+// mission was aborted to send it another command
+func (r *missionRunner) aborted() {
+	r.setState(models.MissionAborted, "")
+
+	r.lock.RLock()
+	hold, lastPos := r.hold, r.lastPos
+	r.lock.RUnlock()
+
+	if hold && lastPos != nil {
+		go func() {
+			err := r.ap.GoToTarget(models.Target{Position: *lastPos})
+			if err != nil {
+				log.Printf("Vehicle '%v' could not hold after its mission was aborted: %v\n", r.ap.vehicleID, err)
+			}
+		}()
+	}
+}
+
+// goTo sends a goto request and waits for the vehicle to accept it while
+// serving pause and abort, the mission fails if the request is rejected,
+// returns false if the mission was aborted or failed
+func (r *missionRunner) goTo(target models.Target) bool {
+	select {
+	case err := <-r.sendGoTo(target):
+		if err != nil {
+			r.setState(models.MissionFailed, fmt.Sprintf("goto failed: %v", err))
+			return false
+		}
+		return true
+	case <-r.pause:
+		// the target is sent again once the mission is resumed
+		return r.paused() && r.goTo(target)
+	case <-r.abort.WaitCh():
+		r.aborted()
+		return false
+	}
+}
+
+// sendGoTo sends a goto request without waiting for its reply
+func (r *missionRunner) sendGoTo(target models.Target) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- r.ap.GoToTarget(target)
+	}()
+
+	return result
+}
+
+// onPosition feeds the runner with the vehicle's position, without blocking
+func (r *missionRunner) onPosition(pos models.Position) {
+	r.lock.Lock()
+	r.lastPos = &pos
+	r.lock.Unlock()
+
+	select {
+	case <-r.positions:
+	default:
+	}
+
+	select {
+	case r.positions <- pos:
+	default:
+	}
+}
+
+// togglePause pauses or resumes the mission depending on its state,
+// returns false if the mission is over
+func (r *missionRunner) togglePause() bool {
+	select {
+	case r.pause <- true:
+		return true
+	case <-r.done.WaitCh():
+		return false
+	}
+}
+
+// Abort stops the mission, the vehicle holds its position if hold
+// is set, otherwise the caller is expected to command it
+func (r *missionRunner) Abort(hold bool) {
+	r.lock.Lock()
+	r.hold = hold
+	r.lock.Unlock()
+
+	r.abort.Done()
+}
+
+// Progress returns where the vehicle is in its mission
+func (r *missionRunner) Progress() models.MissionProgress {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.progress
+}
+
+func (r *missionRunner) setState(state models.MissionState, message string) {
+	r.setProgress(state, r.Progress().Index, message)
+}
+
+// setProgress updates the progress and reports it to the users and admins
+func (r *missionRunner) setProgress(state models.MissionState, index int, message string) {
+	r.lock.Lock()
+	r.progress.State = state
+	r.progress.Index = index
+	r.progress.Message = message
+	progress := r.progress
+	r.lock.Unlock()
+
+	r.ap.sendToUsers(messages.New("mission:progress", progress))
+
+	severity := models.EventInfo
+	if state == models.MissionFailed {
+		severity = models.EventWarning
+	}
+	msg := fmt.Sprintf("mission '%v' %v at waypoint %d/%d", progress.Name, state, progress.Index, progress.Total)
+	if progress.Total == 0 {
+		msg = fmt.Sprintf("mission '%v' %v", progress.Name, state)
+	}
+	if message != "" {
+		msg = fmt.Sprintf("%v: %v", msg, message)
+	}
+	go store.Events.Add(models.NewEvent("mission", r.ap.vehicleID, severity, msg, progress))
+}
+
+// StartMission checks a mission against the active fence and the
+// no fly zones, takes the control from the pilot and runs the mission
+func (ap *Autopilot) StartMission(mission models.Mission) error {
+	if errs := mission.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	if !ap.vehicle.Connected() {
+		return errors.New("Vehicle not connected")
+	}
+	if err := ap.checkSupport("goto"); err != nil {
+		return err
+	}
+
+	start := store.Vehicles.Position(ap.vehicleID)
+	if errs := mission.CheckZones(start, ap.activeFence(), models.GetNoFlyZones()); len(errs) > 0 {
+		return errs
+	}
+
+	return ap.runMission(newMissionRunner(ap, mission))
+}
+
+// runMission runs a mission runner, the pilot loses control
+// unless it is a follow and the rc is refused until it ends
+func (ap *Autopilot) runMission(r *missionRunner) error {
+	ap.lock.Lock()
+	if ap.mission != nil {
+		ap.lock.Unlock()
+		return errors.New("a mission is already running")
+	}
+	if ap.takeover != "" {
+		ap.lock.Unlock()
+		return fmt.Errorf("autopilot forced %v until the end of the flight", ap.takeover)
+	}
+	ap.mission = r
+	ap.lock.Unlock()
+
+	if r.follow == nil {
+		ap.SetPilot("")
+	}
+	ap.StopRcOverride()
+
+	go func() {
+		r.run()
+
+		ap.lock.Lock()
+		if ap.mission == r {
+			ap.mission = nil
+		}
+		ap.lock.Unlock()
+	}()
+
+	return nil
+}
+
+// PauseMission holds the vehicle in place until the mission is resumed
+func (ap *Autopilot) PauseMission() error {
+	r := ap.getMission()
+	if r == nil || r.Progress().State != models.MissionRunning || !r.togglePause() {
+		return errors.New("No mission running")
+	}
+
+	return nil
+}
+
+// ResumeMission resumes a paused mission
+func (ap *Autopilot) ResumeMission() error {
+	r := ap.getMission()
+	if r == nil || r.Progress().State != models.MissionPaused || !r.togglePause() {
+		return errors.New("No mission paused")
+	}
+
+	return nil
+}
+
+// AbortMission stops the running mission, the vehicle
+// holds the position where the mission is aborted
+func (ap *Autopilot) AbortMission() error {
+	r := ap.getMission()
+	if r == nil {
+		return errors.New("No mission running")
+	}
+
+	r.Abort(true)
+
+	return nil
+}
+
+// MissionProgress returns the progress of the running mission
+func (ap *Autopilot) MissionProgress() *models.MissionProgress {
+	r := ap.getMission()
+	if r == nil {
+		return nil
+	}
+
+	progress := r.Progress()
+	return &progress
+}
+
+// activeFence returns the fence of the vehicle if it is enabled
+func (ap *Autopilot) activeFence() *models.Fence {
+	if h := ap.getFence(); h != nil {
+		return h.fence
+	}
+
+	return nil
+}
+
+func (ap *Autopilot) getMission() *missionRunner {
+	ap.lock.RLock()
+	defer ap.lock.RUnlock()
+
+	return ap.mission
+}
+
+// SetGimbal sets the gimbal pitch of the vehicle in degrees
+func (ap *Autopilot) SetGimbal(pitch float64) error {
+	if err := ap.checkSupport("gimbal"); err != nil {
+		return err
+	}
+
+	data := libs.JSONObject{"pitch": pitch}
+
+	cb := callback.New()
+	store.Audit.Record(models.NewAuditEntry(models.AuditSourceAutopilot, "", ap.vehicleID, "gimbal", data), cb)
+	ap.vehicle.Send(messages.NewRequest("gimbal", data, cb))
+	_, err := cb.Timeout(time.Minute).Wait()
+
+	return err
+}
@@ -0,0 +1,179 @@
+package autopilot
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// separationInterval is the period at which the separation between vehicles is checked
+var separationInterval = 250 * time.Millisecond
+
+// separationStale is the age after which a position is not used to check separation
+var separationStale = 3 * time.Second
+
+// separationMonitor keeps flying vehicles apart from each other
+type separationMonitor struct {
+	lock        sync.RWMutex
+	separations []models.Separation
+
+	conflicts map[[2]string]bool // only used by the monitor loop
+	limited   map[string]bool    // only used by the monitor loop
+}
+
+var monitor = &separationMonitor{
+	separations: []models.Separation{},
+	conflicts:   make(map[[2]string]bool),
+	limited:     make(map[string]bool),
+}
+
+// MonitorSeparation checks the separation between every pair of flying
+// vehicles and limits the rc of the vehicles in conflict, never returns
+func MonitorSeparation() {
+	ticker := time.NewTicker(separationInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		monitor.check(store.Separation.Settings())
+	}
+}
+
+// Separations returns the last computed separation
+// between every pair of flying vehicles
+func Separations() []models.Separation {
+	monitor.lock.RLock()
+	defer monitor.lock.RUnlock()
+
+	return monitor.separations
+}
+
+func (m *separationMonitor) check(settings models.SeparationSettings) {
+	now := time.Now()
+	telemetry := store.Vehicles.TelemetryJSON(now.Add(-separationStale), nil)
+
+	ids := []string{}
+	for vehicleID, t := range telemetry {
+		// vehicles without an autopilot are not connected to this hive
+		if t.Status.Armed && now.Sub(t.Position.Timestamp) < separationStale && running(vehicleID) != nil {
+			ids = append(ids, vehicleID)
+		}
+	}
+	sort.Strings(ids)
+
+	separations := []models.Separation{}
+	conflicts := make(map[[2]string]bool)
+	limits := make(map[string]models.RcLimits)
+
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			s := models.NewSeparation(a, telemetry[a].Position, b, telemetry[b].Position, settings)
+			separations = append(separations, s)
+			if !s.Conflict {
+				continue
+			}
+
+			conflicts[s.VehicleIDs] = true
+			if !m.conflicts[s.VehicleIDs] {
+				m.onConflict(s)
+			}
+
+			for _, vehicleID := range s.VehicleIDs {
+				l, ok := limits[vehicleID]
+				if !ok {
+					l = models.NewRcLimits()
+				}
+				limits[vehicleID] = l.Merge(s.Limits(vehicleID, settings))
+			}
+		}
+	}
+
+	for pair := range m.conflicts {
+		if !conflicts[pair] {
+			m.onClear(pair)
+		}
+	}
+
+	limited := make(map[string]bool)
+	for vehicleID, l := range limits {
+		l := l
+		running(vehicleID).setSeparationLimits(&l)
+		limited[vehicleID] = true
+	}
+	for vehicleID := range m.limited {
+		if !limited[vehicleID] {
+			running(vehicleID).setSeparationLimits(nil)
+		}
+	}
+
+	m.conflicts = conflicts
+	m.limited = limited
+
+	m.lock.Lock()
+	m.separations = separations
+	m.lock.Unlock()
+}
+
+// onConflict pauses the missions of both vehicles, the rc
+// is limited until the conflict is over but missions stay
+// paused until an admin resumes them
+func (m *separationMonitor) onConflict(s models.Separation) {
+	severity := models.EventWarning
+	if s.Violated {
+		severity = models.EventCritical
+	}
+
+	msg := fmt.Sprintf("vehicles '%v' and '%v' are %.0fm apart horizontally and %.0fm vertically, closing at %.1fm/s",
+		s.VehicleIDs[0], s.VehicleIDs[1], s.Horizontal, s.Vertical, s.ClosingSpeed)
+
+	for _, vehicleID := range s.VehicleIDs {
+		ap := running(vehicleID)
+
+		// missions fly with goto requests that the rc limits do not affect
+		go ap.PauseMission()
+
+		ap.sendToUsers(messages.New("separation", s))
+		go store.Events.Add(models.NewEvent("separation", vehicleID, severity, msg, s))
+	}
+}
+
+func (m *separationMonitor) onClear(pair [2]string) {
+	msg := fmt.Sprintf("separation between vehicles '%v' and '%v' restored", pair[0], pair[1])
+	for _, vehicleID := range pair {
+		go store.Events.Add(models.NewEvent("separation", vehicleID, models.EventInfo, msg, nil))
+	}
+}
+
+// setSeparationLimits sets the rc limits keeping the vehicle apart
+// from the others, nil removes them, the rc is overridden while the
+// vehicle is limited unless the autopilot took over the vehicle
+func (ap *Autopilot) setSeparationLimits(limits *models.RcLimits) {
+	ap.lock.Lock()
+	ap.separation = limits
+	takeover := ap.takeover
+	ap.lock.Unlock()
+
+	if limits == nil {
+		ap.StopRcOverride()
+		return
+	}
+
+	if takeover == "" {
+		err := ap.StartRcOverride()
+		if err != nil {
+			log.Printf("Could not limit the rc of vehicle '%v': %v\n", ap.vehicleID, err)
+		}
+	}
+}
+
+func (ap *Autopilot) separationLimits() *models.RcLimits {
+	ap.lock.RLock()
+	defer ap.lock.RUnlock()
+
+	return ap.separation
+}
@@ -0,0 +1,39 @@
+package autopilot
+
+import (
+	"testing"
+
+	"github.com/volons/hive/models"
+)
+
+func TestSeparationConflictLimitsRc(t *testing.T) {
+	ap, vehicle := newTestAutopilot(t)
+
+	// the other vehicle is 10m north, closer than the minimum
+	settings := models.DefaultSeparationSettings()
+	pos := models.NewPoint(48.85, 2.35, 20)
+	s := models.NewSeparation(ap.vehicleID, pos, "other", pos.Translate(0, 10, 0), settings)
+	if !s.Conflict {
+		t.Fatal("vehicles 10m apart are not in conflict")
+	}
+
+	ap.SetRCValues(models.NewRc(0, 0, 1, 0, 0))
+	limits := s.Limits(ap.vehicleID, settings)
+	ap.setSeparationLimits(&limits)
+
+	if rc := nextMessage(t, vehicle, "rc").Data.(*models.Rc); rc.Pitch() > 0.01 {
+		t.Errorf("pitch toward the other vehicle is %.3f, should be 0", rc.Pitch())
+	}
+
+	// the rc being sent may have been computed before the update
+	ap.SetRCValues(models.NewRc(0, 0, -1, 0, 0))
+	nextMessage(t, vehicle, "rc")
+	if rc := nextMessage(t, vehicle, "rc").Data.(*models.Rc); rc.Pitch() != -1 {
+		t.Errorf("pitch away from the other vehicle is limited to %.3f", rc.Pitch())
+	}
+
+	ap.setSeparationLimits(nil)
+	if ap.overridingRc.Get() {
+		t.Error("rc still overridden once the conflict is over")
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/libs"
 )
 
 type sessionKey string
@@ -13,26 +13,29 @@ var sessions sync.Map
 
 // Session represents a vehicle controlling user's session
 type Session struct {
+	lock      sync.RWMutex
 	vehicleID string
 	userID    string
 	start     time.Time
 	duration  time.Duration
-	done      chan bool
+	extend    chan time.Duration
 	stop      chan bool
+	done      libs.Done
 }
 
 // StartSession starts a user session
-func StartSession(vehicleID, userID string) *Session {
-	session := newSession(vehicleID, userID, time.Minute*3)
+// returns true if a previous session was resumed
+func StartSession(vehicleID, userID string, duration time.Duration) (*Session, bool) {
+	session := newSession(vehicleID, userID, duration)
 	val, loaded := sessions.LoadOrStore(sessionKey(userID), session)
 	if loaded {
 		// if session already exists restore previous session
 		session := val.(*Session)
-		return session
+		return session, true
 	}
 
 	go session.Start()
-	return session
+	return session, false
 }
 
 // newSession creates a new session
@@ -42,21 +45,38 @@ func newSession(vehicleID, userID string, duration time.Duration) *Session {
 		userID:    userID,
 		start:     time.Now(),
 		duration:  duration,
-		done:      make(chan bool),
+		extend:    make(chan time.Duration),
 		stop:      make(chan bool),
+		done:      libs.NewDone(),
 	}
 }
 
 // Start runns the session
 func (s *Session) Start() {
-	select {
-	case <-time.After(s.duration):
-	case <-s.stop:
+	timer := time.NewTimer(s.TimeLeft())
+	defer timer.Stop()
+
+loop:
+	for {
+		select {
+		case d := <-s.extend:
+			s.lock.Lock()
+			s.duration += d
+			s.lock.Unlock()
+
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(s.TimeLeft())
+		case <-timer.C:
+			break loop
+		case <-s.stop:
+			break loop
+		}
 	}
+
 	sessions.Delete(sessionKey(s.userID))
-	user := store.Users.Get(s.userID)
-	store.Users.Delete(user)
-	close(s.done)
+	s.done.Done()
 }
 
 // Stop stops the session
@@ -65,13 +85,32 @@ func (s *Session) Stop() bool {
 	select {
 	case s.stop <- true:
 		return true
-	default:
+	case <-s.done.WaitCh():
+		return false
+	}
+}
+
+// Extend adds d to the duration of the session
+// returns true if it was running
+func (s *Session) Extend(d time.Duration) bool {
+	select {
+	case s.extend <- d:
+		return true
+	case <-s.done.WaitCh():
 		return false
 	}
 }
 
+// UserID returns the ID of the user owning the session
+func (s *Session) UserID() string {
+	return s.userID
+}
+
 // TimeLeft returns the time left on this session
 func (s *Session) TimeLeft() time.Duration {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
 	elapsed := time.Since(s.start)
 	left := s.duration - elapsed
 	if left < 0 {
@@ -80,3 +119,8 @@ func (s *Session) TimeLeft() time.Duration {
 
 	return left
 }
+
+// Done returns a channel closed when the session ends
+func (s *Session) Done() <-chan bool {
+	return s.done.WaitCh()
+}
@@ -0,0 +1,130 @@
+package autopilot
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+)
+
+// StartOrResumeSession starts a user session or resumes
+// the previous one if the user reconnects
+func (ap *Autopilot) StartOrResumeSession(userID string) *Session {
+	settings := store.Vehicles.Settings(ap.vehicleID).Session
+	session, resumed := StartSession(ap.vehicleID, userID, settings.DurationTime())
+
+	ap.lock.Lock()
+	watching := ap.session == session
+	ap.session = session
+	ap.lock.Unlock()
+
+	if !resumed || !watching {
+		go ap.watchSession(session, settings.EndAction)
+	}
+
+	ap.sendCountdown(session)
+
+	return session
+}
+
+// GetSession returns the running session
+func (ap *Autopilot) GetSession() *Session {
+	ap.lock.RLock()
+	defer ap.lock.RUnlock()
+	return ap.session
+}
+
+// ExtendSession adds d to the duration of the running session
+// and returns the time left
+func (ap *Autopilot) ExtendSession(d time.Duration) (time.Duration, error) {
+	session := ap.GetSession()
+	if session == nil || !session.Extend(d) {
+		return 0, errors.New("No session running")
+	}
+
+	ap.sendCountdown(session)
+
+	return session.TimeLeft(), nil
+}
+
+// stopSession stops the running session without its end action
+func (ap *Autopilot) stopSession() {
+	ap.lock.Lock()
+	session := ap.session
+	ap.session = nil
+	ap.lock.Unlock()
+
+	if session != nil {
+		session.Stop()
+	}
+}
+
+// EndSession stops the running session
+func (ap *Autopilot) EndSession() error {
+	session := ap.GetSession()
+	if session == nil || !session.Stop() {
+		return errors.New("No session running")
+	}
+
+	return nil
+}
+
+func (ap *Autopilot) watchSession(session *Session, endAction string) {
+	countdown := time.NewTicker(time.Second)
+	defer countdown.Stop()
+
+	for {
+		select {
+		case <-countdown.C:
+			ap.sendCountdown(session)
+		case <-session.Done():
+			ap.endSession(session, endAction)
+			return
+		case <-ap.Done():
+			session.Stop()
+			return
+		}
+	}
+}
+
+func (ap *Autopilot) sendCountdown(session *Session) {
+	ap.sendToUsers(messages.New("countdown", libs.JSONObject{
+		"time": session.TimeLeft().Seconds(),
+	}))
+}
+
+// endSession takes control back from the user and
+// safely brings the vehicle back
+func (ap *Autopilot) endSession(session *Session, endAction string) {
+	ap.lock.Lock()
+	if ap.session != session {
+		ap.lock.Unlock()
+		return
+	}
+	ap.session = nil
+	if ap.pilot == session.UserID() {
+		ap.pilot = ""
+	}
+	ap.lock.Unlock()
+
+	log.Printf("Session of user '%v' on vehicle '%v' ended\n", session.UserID(), ap.vehicleID)
+
+	ap.sendToUsers(messages.New("session:end", libs.JSONObject{
+		"userID": session.UserID(),
+	}))
+	// The user's token is revoked so that it cannot start a new session
+	if user := store.Users.GetByID(session.UserID()); user != nil {
+		store.Users.Delete(user)
+	}
+	ap.DisconnectUser(session.UserID())
+	ap.sendControl()
+	ap.StopRcOverride()
+
+	err := ap.Command(endAction)
+	if err != nil {
+		log.Printf("Could not %v vehicle '%v' at the end of session: %v\n", endAction, ap.vehicleID, err)
+	}
+}
@@ -0,0 +1,57 @@
+package autopilot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionExtend(t *testing.T) {
+	s, resumed := StartSession("vehicle", t.Name(), 100*time.Millisecond)
+	if resumed {
+		t.Fatal("new session resumed")
+	}
+
+	if !s.Extend(200 * time.Millisecond) {
+		t.Fatal("running session not extended")
+	}
+
+	select {
+	case <-s.Done():
+		t.Fatal("session ended before the end of its extension")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	select {
+	case <-s.Done():
+	case <-time.After(time.Second):
+		t.Fatal("extended session did not end")
+	}
+
+	if s.Extend(time.Second) {
+		t.Error("ended session extended")
+	}
+}
+
+func TestSessionResume(t *testing.T) {
+	s, _ := StartSession("vehicle", t.Name(), time.Minute)
+
+	resumed, ok := StartSession("vehicle", t.Name(), time.Minute)
+	if !ok || resumed != s {
+		t.Fatal("running session of the user not resumed")
+	}
+
+	if !s.Stop() {
+		t.Fatal("running session not stopped")
+	}
+	<-s.Done()
+
+	if s.Stop() {
+		t.Error("stopped session stopped again")
+	}
+
+	next, ok := StartSession("vehicle", t.Name(), time.Minute)
+	defer next.Stop()
+	if ok || next == s {
+		t.Error("stopped session resumed")
+	}
+}
@@ -2,34 +2,84 @@ package autopilot
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"time"
 
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/callback"
+	"github.com/volons/hive/libs/store"
 	"github.com/volons/hive/messages"
 	"github.com/volons/hive/models"
 )
 
-func (ap *Autopilot) handleUserMessage(msg messages.Message) {
+func (ap *Autopilot) handleUserMessage(userID string, msg messages.Message) {
+	if !observerMessages[msg.Type] && !ap.HasControl(userID) {
+		ap.deny(userID, msg, "control", "user does not have control")
+		return
+	}
+
+	if missionMessages[msg.Type] && ap.getMission() != nil {
+		ap.deny(userID, msg, "mission", "a mission is running")
+		return
+	}
+
+	if feature, ok := models.MessagePermission(msg.Type); ok && !ap.Allowed(userID, feature) {
+		ap.deny(userID, msg, "permission", fmt.Sprintf("permission '%v' not granted", feature))
+		return
+	}
+
+	if !ap.Supports(msg.Type) {
+		ap.deny(userID, msg, "unsupported", fmt.Sprintf("vehicle does not support '%v'", msg.Type))
+		return
+	}
+
 	switch msg.Type {
 	case "rc":
 		ap.onRc(msg)
+	case "follow:target":
+		ap.onFollowTarget(userID, msg)
 	default:
-		ap.forwardToVehicle(msg)
+		ap.forwardToVehicle(userID, msg)
+	}
+}
+
+// deny rejects a user message and tells the user why
+func (ap *Autopilot) deny(userID string, msg messages.Message, reason string, message string) {
+	err := fmt.Errorf("not allowed: %v", message)
+	if msg.IsRequest() {
+		msg.Reply(nil, err)
 	}
+
+	ap.sendToUser(userID, messages.New("error", libs.JSONObject{
+		"message": err.Error(),
+		"action":  "denied",
+		"reason":  reason,
+		"id":      msg.ID,
+		"type":    msg.Type,
+	}))
 }
 
 func (ap *Autopilot) onRc(msg messages.Message) {
 	rc, ok := msg.Data.(*models.Rc)
 	if ok {
+		ap.lastPilotInput = time.Now()
 		ap.SetRCValues(rc)
 	}
 }
 
-func (ap *Autopilot) forwardToVehicle(msg messages.Message) {
+func (ap *Autopilot) forwardToVehicle(userID string, msg messages.Message) {
+	entry := models.NewUserAuditEntry(userID, ap.vehicleID, msg.Type, msg.Data)
+
 	err := ap.vehicle.Send(msg)
 	if err != nil {
 		log.Println("Autopilot could not send to vehicle:", err)
+		entry.SetResult(nil, err)
+		store.Audit.Add(entry)
+		return
 	}
+
+	store.Audit.Record(entry, msg.Callback())
 }
 
 //// TakeOff tells the vehcile to takeoff
@@ -82,6 +132,24 @@ func (ap *Autopilot) forwardToVehicle(msg messages.Message) {
 //	return nil
 //}
 
+// Command sends a command without parameters such as takeoff,
+// land or rtl to the vehicle and waits for its reply
+func (ap *Autopilot) Command(typ string) error {
+	if !ap.vehicle.Connected() {
+		return errors.New("Vehicle not connected")
+	}
+	if err := ap.checkSupport(typ); err != nil {
+		return err
+	}
+
+	cb := callback.New()
+	store.Audit.Record(models.NewAuditEntry(models.AuditSourceAutopilot, "", ap.vehicleID, typ, nil), cb)
+	ap.vehicle.Send(messages.NewRequest(typ, nil, cb))
+	_, err := cb.Timeout(time.Minute).Wait()
+
+	return err
+}
+
 // SetRCValues allows to control the vehicle
 func (ap *Autopilot) SetRCValues(rc *models.Rc) error {
 	ap.manualRc.Set(rc)
@@ -0,0 +1,275 @@
+package autopilot
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/store"
+	"github.com/volons/hive/messages"
+	"github.com/volons/hive/models"
+)
+
+// observerMessages lists the user messages that users without
+// control are allowed to send, every other message needs control
+var observerMessages = map[string]bool{
+	"webrtc:start":        true,
+	"webrtc:sdp":          true,
+	"webrtc:icecandidate": true,
+}
+
+// missionMessages lists the user messages refused while a mission
+// flies the vehicle, since they would fight the mission's gotos
+var missionMessages = map[string]bool{
+	"rc":   true,
+	"goto": true,
+}
+
+// userConn is a user connected to the autopilot
+type userConn struct {
+	line        *messages.Line
+	permissions models.Permissions
+}
+
+type userMessage struct {
+	userID string
+	msg    messages.Message
+}
+
+// ConnectUser should be called by the user that wishes to listen to this vehicle,
+// a user connecting again with the same ID replaces its previous connection
+func (ap *Autopilot) ConnectUser(userID string, permissions models.Permissions, user *messages.Line) {
+	line := messages.NewLine(fmt.Sprintf("autopilot:%v:user:%v", ap.vehicleID, userID), true)
+	line.Connect(user)
+
+	ap.lock.Lock()
+	prev := ap.users[userID]
+	ap.users[userID] = &userConn{
+		line:        line,
+		permissions: permissions.Copy(),
+	}
+	ap.lock.Unlock()
+
+	if prev != nil {
+		prev.line.Disconnect()
+	}
+
+	go ap.listenToUser(userID, line)
+
+	ap.sendControl()
+}
+
+func (ap *Autopilot) listenToUser(userID string, line *messages.Line) {
+	for {
+		select {
+		case msg := <-line.Recv():
+			select {
+			case ap.userMsgs <- userMessage{userID, msg}:
+			case <-line.Done():
+				ap.removeUser(userID, line)
+				return
+			case <-ap.Done():
+				return
+			}
+		case <-line.Done():
+			ap.removeUser(userID, line)
+			return
+		case <-ap.Done():
+			return
+		}
+	}
+}
+
+func (ap *Autopilot) removeUser(userID string, line *messages.Line) {
+	ap.lock.Lock()
+	conn := ap.users[userID]
+	removed := conn != nil && conn.line == line
+	if removed {
+		delete(ap.users, userID)
+	}
+	ap.lock.Unlock()
+
+	if removed {
+		ap.sendControl()
+	}
+}
+
+// DisconnectUser disconnects a user from this vehicle
+func (ap *Autopilot) DisconnectUser(userID string) {
+	ap.lock.RLock()
+	conn := ap.users[userID]
+	ap.lock.RUnlock()
+
+	if conn != nil {
+		conn.line.Disconnect()
+	}
+}
+
+// GetUsers returns the IDs of the users connected to this vehicle
+func (ap *Autopilot) GetUsers() []string {
+	ap.lock.RLock()
+	defer ap.lock.RUnlock()
+
+	ids := []string{}
+	for userID := range ap.users {
+		ids = append(ids, userID)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// sendToUsers sends a message to every user connected to this vehicle
+func (ap *Autopilot) sendToUsers(msg messages.Message) {
+	ap.lock.RLock()
+	lines := make([]*messages.Line, 0, len(ap.users))
+	for _, conn := range ap.users {
+		lines = append(lines, conn.line)
+	}
+	ap.lock.RUnlock()
+
+	for _, line := range lines {
+		line.Send(msg)
+	}
+}
+
+// sendToUser sends a message to a single user connected to this vehicle
+func (ap *Autopilot) sendToUser(userID string, msg messages.Message) error {
+	ap.lock.RLock()
+	conn := ap.users[userID]
+	ap.lock.RUnlock()
+
+	if conn == nil {
+		return errors.New("user not connected")
+	}
+
+	return conn.line.Send(msg)
+}
+
+// SetPermissions updates the permissions of a connected user
+func (ap *Autopilot) SetPermissions(userID string, permissions models.Permissions) {
+	ap.lock.Lock()
+	conn := ap.users[userID]
+	if conn != nil {
+		conn.permissions = permissions.Copy()
+	}
+	ap.lock.Unlock()
+
+	if conn != nil {
+		ap.sendToUser(userID, messages.New("update:permissions", permissions.JSON()))
+	}
+}
+
+// Allowed checks if a connected user was granted a feature
+func (ap *Autopilot) Allowed(userID string, feature string) bool {
+	ap.lock.RLock()
+	defer ap.lock.RUnlock()
+
+	conn := ap.users[userID]
+	return conn != nil && conn.permissions.Allowed(feature)
+}
+
+// GetPilot gets the the current pilot userID
+func (ap *Autopilot) GetPilot() string {
+	ap.lock.RLock()
+	defer ap.lock.RUnlock()
+	return ap.pilot
+}
+
+// SetPilot sets userID as the current pilot,
+// an empty userID revokes the control from every user
+func (ap *Autopilot) SetPilot(userID string) {
+	ap.lock.Lock()
+	changed := ap.pilot != userID
+	ap.pilot = userID
+	if changed {
+		ap.pilotAt = time.Now()
+	}
+	ap.lock.Unlock()
+
+	if changed {
+		// Do not keep applying the previous pilot's controls
+		ap.manualRc.Set(ap.nullRc)
+		ap.sendControl()
+	}
+}
+
+// HandOver gives control to a user connected to the vehicle, the
+// previous pilot's session is stopped and the user's one is started
+func (ap *Autopilot) HandOver(userID string) error {
+	ap.lock.RLock()
+	_, connected := ap.users[userID]
+	locked := ap.controlLocked()
+	ap.lock.RUnlock()
+
+	if !connected {
+		return fmt.Errorf("user '%s' is not connected to vehicle '%s'", userID, ap.vehicleID)
+	}
+	if locked != nil {
+		return locked
+	}
+
+	if session := ap.GetSession(); session != nil && session.UserID() != userID {
+		ap.stopSession()
+	}
+
+	ap.SetPilot(userID)
+	ap.StartOrResumeSession(userID)
+
+	return nil
+}
+
+// ClaimControl sets userID as the current pilot if nobody has control
+// returns true if the user has control
+func (ap *Autopilot) ClaimControl(userID string) bool {
+	ap.lock.Lock()
+	claimed := ap.pilot == "" && ap.controlLocked() == nil
+	if claimed {
+		ap.pilot = userID
+		ap.pilotAt = time.Now()
+	}
+	hasControl := ap.pilot == userID
+	ap.lock.Unlock()
+
+	if claimed {
+		ap.sendControl()
+	}
+
+	return hasControl
+}
+
+// controlLocked returns why users cannot get control of the vehicle,
+// nil if they can, the caller should hold the lock
+func (ap *Autopilot) controlLocked() error {
+	if ap.takeover != "" {
+		return fmt.Errorf("autopilot forced %v until the end of the flight", ap.takeover)
+	}
+
+	// the pilot keeps control during a follow to send its targets
+	if ap.mission != nil && ap.mission.follow == nil {
+		return errors.New("a mission is running")
+	}
+
+	return nil
+}
+
+// HasControl checks if the provided user has control
+// over this vehicle
+func (ap *Autopilot) HasControl(userID string) bool {
+	ap.lock.RLock()
+	defer ap.lock.RUnlock()
+	return ap.pilot != "" && ap.pilot == userID
+}
+
+// sendControl tells every connected user who
+// the pilot is and who is observing
+func (ap *Autopilot) sendControl() {
+	store.Vehicles.SetPilot(ap.vehicleID, ap.GetPilot())
+
+	ap.sendToUsers(messages.New("pilot", libs.JSONObject{
+		"userID": ap.GetPilot(),
+		"users":  ap.GetUsers(),
+	}))
+}
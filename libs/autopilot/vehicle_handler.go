@@ -1,6 +1,8 @@
 package autopilot
 
 import (
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/volons/hive/libs/store"
@@ -16,6 +18,8 @@ func (ap *Autopilot) handleVehicleMessage(msg messages.Message) {
 		ap.onBatteryMessage(msg)
 	case "status":
 		ap.onStatusMessage(msg)
+	case "caps":
+		ap.onCapsMessage(msg)
 	default:
 		ap.forwardToUser(msg)
 	}
@@ -28,6 +32,7 @@ func (ap *Autopilot) onStatusMessage(msg messages.Message) {
 	}
 
 	store.Vehicles.SetStatus(ap.vehicleID, status)
+	ap.trackFlight(status.Armed)
 
 	ap.forwardToUser(msg)
 }
@@ -39,10 +44,18 @@ func (ap *Autopilot) onPositionMessage(msg messages.Message) {
 	}
 
 	pos.Timestamp = time.Now()
+	ap.lastTelemetry = pos.Timestamp
+	ap.heading = pos.Hdg
 	store.Vehicles.SetPosition(ap.vehicleID, pos)
+	if ap.flight != nil {
+		ap.flight.AddPosition(*pos)
+	}
+	if r := ap.getMission(); r != nil {
+		r.onPosition(*pos)
+	}
 
-	if ap.fence != nil {
-		ap.fence.checkFence(*pos)
+	if fence := ap.getFence(); fence != nil {
+		fence.checkFence(*pos)
 	}
 
 	ap.forwardToUser(msg)
@@ -55,10 +68,64 @@ func (ap *Autopilot) onBatteryMessage(msg messages.Message) {
 	}
 
 	store.Vehicles.SetBattery(ap.vehicleID, batt)
+	if ap.flight != nil {
+		ap.flight.SetBattery(*batt)
+	}
 
 	ap.forwardToUser(msg)
+	ap.checkBattery(*batt)
 }
 
 func (ap *Autopilot) forwardToUser(msg messages.Message) {
-	ap.user.Send(msg)
+	ap.sendToUsers(msg)
+}
+
+// openFlightInterval is the period at which a running flight is saved
+var openFlightInterval = 30 * time.Second
+
+// trackFlight starts a flight when the vehicle gets armed and saves it
+// once the vehicle is disarmed, while it runs it is saved when it starts,
+// when its pilot changes and every interval
+func (ap *Autopilot) trackFlight(armed bool) {
+	if armed && ap.flight == nil {
+		ap.flight = models.NewFlight(ap.vehicleID, ap.GetPilot())
+		if batt := store.Vehicles.Battery(ap.vehicleID); batt != nil {
+			ap.flight.SetBattery(*batt)
+		}
+		ap.batteryTakeOver(fmt.Sprintf("battery level %v at the start of the flight", ap.batteryLevel))
+	} else if armed {
+		pilot := ap.flight.Pilot
+		ap.flight.SetPilot(ap.GetPilot())
+
+		if ap.flight.Pilot == pilot && time.Since(ap.flightSavedAt) < openFlightInterval {
+			return
+		}
+	} else {
+		ap.endFlight()
+		return
+	}
+
+	// Saved while it runs so that it can be closed after a restart
+	ap.flightSavedAt = time.Now()
+	err := store.Flights.SetOpen(ap.flight)
+	if err != nil {
+		log.Println("Could not save open flight", err)
+	}
+}
+
+// endFlight saves the running flight, if any
+func (ap *Autopilot) endFlight() {
+	if ap.flight == nil {
+		return
+	}
+
+	flight := ap.flight
+	ap.flight = nil
+
+	ap.lock.Lock()
+	ap.takeover = ""
+	ap.lock.Unlock()
+
+	flight.Finish()
+	go store.Flights.Add(flight)
 }
@@ -0,0 +1,16 @@
+// Package formats converts hive data to and
+// from standard geographic file formats
+package formats
+
+import "encoding/xml"
+
+const creator = "volons hive"
+
+func encodeXML(doc interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,225 @@
+package formats
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/models"
+)
+
+type geoJSON struct {
+	Type       string           `json:"type"`
+	Features   []geoJSONFeature `json:"features,omitempty"`
+	Geometry   *geoJSONGeometry `json:"geometry,omitempty"`
+	Properties libs.JSONObject  `json:"properties,omitempty"`
+}
+
+type geoJSONFeature struct {
+	Type       string           `json:"type"`
+	Geometry   *geoJSONGeometry `json:"geometry"`
+	Properties libs.JSONObject  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// ImportGeoJSON reads a GeoJSON feature collection, a feature or a geometry:
+// polygons make the fence, or the no fly zones when their "zone" property is
+// "nofly", a line string makes the route, floor, ceiling and speed are read
+// from the properties
+func ImportGeoJSON(data []byte) (*Plan, error) {
+	var doc geoJSON
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var features []geoJSONFeature
+	switch doc.Type {
+	case "FeatureCollection":
+		features = doc.Features
+	case "Feature":
+		features = []geoJSONFeature{{Type: doc.Type, Geometry: doc.Geometry, Properties: doc.Properties}}
+	default:
+		var geometry geoJSONGeometry
+		err := json.Unmarshal(data, &geometry)
+		if err != nil {
+			return nil, err
+		}
+		features = []geoJSONFeature{{Type: "Feature", Geometry: &geometry}}
+	}
+
+	plan := &Plan{}
+	errs := FeatureErrors{}
+
+	for i, f := range features {
+		feature := fmt.Sprintf("feature %d", i)
+		props := f.Properties
+		if props == nil {
+			props = libs.JSONObject{}
+		}
+		if name, ok := props.GetString("name"); ok {
+			feature = fmt.Sprintf("feature %d '%s'", i, name)
+		}
+
+		if f.Geometry == nil {
+			errs.add(feature, "no geometry")
+			continue
+		}
+
+		switch f.Geometry.Type {
+		case "Polygon":
+			var rings [][][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &rings); err != nil || len(rings) == 0 {
+				errs.add(feature, "bad polygon coordinates")
+				continue
+			}
+			if len(rings) > 1 {
+				errs.add(feature, "polygons with holes are not supported")
+				continue
+			}
+
+			zone := models.ZoneData{Ceiling: defaultCeiling}
+			if floor, ok := props.GetNumber("floor"); ok {
+				zone.Floor = floor
+			}
+			if ceiling, ok := props.GetNumber("ceiling"); ok {
+				zone.Ceiling = ceiling
+			}
+
+			points, ok := geoJSONPoints(rings[0])
+			if !ok {
+				errs.add(feature, "bad polygon coordinates")
+				continue
+			}
+			// the first point of a GeoJSON ring is repeated at its end
+			if len(points) > 1 && points[0] == points[len(points)-1] {
+				points = points[:len(points)-1]
+			}
+			for _, p := range points {
+				zone.Points = append(zone.Points, models.PointData{Lat: p.Lat, Lon: p.Lon})
+			}
+
+			zoneType, _ := props.GetString("zone")
+			plan.addZone(&errs, feature, zone, zoneType == "nofly")
+		case "LineString":
+			var coords [][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &coords); err != nil {
+				errs.add(feature, "bad line string coordinates")
+				continue
+			}
+
+			points, ok := geoJSONPoints(coords)
+			if !ok {
+				errs.add(feature, "bad line string coordinates")
+				continue
+			}
+
+			speed, _ := props.GetNumber("speed")
+			waypoints := []models.Waypoint{}
+			for _, p := range points {
+				waypoints = append(waypoints, models.Waypoint{Lat: p.Lat, Lon: p.Lon, RelAlt: p.Alt, Speed: speed})
+			}
+
+			name, _ := props.GetString("name")
+			plan.setRoute(&errs, feature, name, waypoints)
+		default:
+			errs.add(feature, "%s geometries are not supported", f.Geometry.Type)
+		}
+	}
+
+	return plan.result(errs)
+}
+
+// geoJSONPoints converts [lon, lat, alt] positions,
+// the altitude is relative to the launch point
+func geoJSONPoints(coords [][]float64) ([]models.PointData, bool) {
+	points := []models.PointData{}
+	for _, c := range coords {
+		if len(c) < 2 {
+			return nil, false
+		}
+
+		p := models.PointData{Lon: c[0], Lat: c[1]}
+		if len(c) > 2 {
+			p.Alt = c[2]
+		}
+		points = append(points, p)
+	}
+
+	return points, true
+}
+
+// ExportGeoJSON writes a plan as a GeoJSON feature collection
+func ExportGeoJSON(plan Plan) ([]byte, error) {
+	doc := geoJSON{
+		Type:     "FeatureCollection",
+		Features: []geoJSONFeature{},
+	}
+
+	var fence []models.ZoneData
+	if plan.Fence != nil {
+		fence = plan.Fence.Zones
+	}
+	for _, zone := range fence {
+		feature, err := geoJSONZone(zone, "fence")
+		if err != nil {
+			return nil, err
+		}
+		doc.Features = append(doc.Features, feature)
+	}
+	for _, zone := range plan.NoFly {
+		feature, err := geoJSONZone(zone, "nofly")
+		if err != nil {
+			return nil, err
+		}
+		doc.Features = append(doc.Features, feature)
+	}
+
+	if plan.Mission != nil {
+		coords := [][]float64{}
+		for _, w := range plan.Mission.Waypoints {
+			coords = append(coords, []float64{w.Lon, w.Lat, w.RelAlt})
+		}
+
+		feature, err := geoJSONFeatureOf("LineString", coords, libs.JSONObject{"name": plan.Mission.Name})
+		if err != nil {
+			return nil, err
+		}
+		doc.Features = append(doc.Features, feature)
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func geoJSONZone(zone models.ZoneData, zoneType string) (geoJSONFeature, error) {
+	ring := [][]float64{}
+	for _, p := range zone.Points {
+		ring = append(ring, []float64{p.Lon, p.Lat})
+	}
+	if len(ring) > 0 {
+		ring = append(ring, ring[0])
+	}
+
+	return geoJSONFeatureOf("Polygon", [][][]float64{ring}, libs.JSONObject{
+		"zone":    zoneType,
+		"floor":   zone.Floor,
+		"ceiling": zone.Ceiling,
+	})
+}
+
+func geoJSONFeatureOf(geometryType string, coords interface{}, props libs.JSONObject) (geoJSONFeature, error) {
+	raw, err := json.Marshal(coords)
+	if err != nil {
+		return geoJSONFeature{}, err
+	}
+
+	return geoJSONFeature{
+		Type:       "Feature",
+		Geometry:   &geoJSONGeometry{Type: geometryType, Coordinates: raw},
+		Properties: props,
+	}, nil
+}
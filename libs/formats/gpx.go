@@ -0,0 +1,52 @@
+package formats
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/volons/hive/models"
+)
+
+type gpxDoc struct {
+	XMLName xml.Name `xml:"gpx"`
+	Version string   `xml:"version,attr"`
+	Creator string   `xml:"creator,attr"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Track   gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Name    string     `xml:"name"`
+	Segment []gpxPoint `xml:"trkseg>trkpt"`
+}
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Ele  float64 `xml:"ele"`
+	Time string  `xml:"time"`
+}
+
+// GPX encodes a track as a GPX 1.1 document
+func GPX(name string, track []models.Position) ([]byte, error) {
+	doc := gpxDoc{
+		Version: "1.1",
+		Creator: creator,
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		Track: gpxTrack{
+			Name:    name,
+			Segment: []gpxPoint{},
+		},
+	}
+
+	for _, pos := range track {
+		doc.Track.Segment = append(doc.Track.Segment, gpxPoint{
+			Lat:  pos.Lat,
+			Lon:  pos.Lon,
+			Ele:  pos.Alt,
+			Time: pos.Timestamp.UTC().Format(time.RFC3339Nano),
+		})
+	}
+
+	return encodeXML(doc)
+}
@@ -0,0 +1,283 @@
+package formats
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/volons/hive/models"
+)
+
+type kmlDoc struct {
+	XMLName   xml.Name       `xml:"kml"`
+	Xmlns     string         `xml:"xmlns,attr"`
+	Document  kmlDocument    `xml:"Document"`
+	Placemark []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlDocument struct {
+	Name      string         `xml:"name"`
+	Placemark []kmlPlacemark `xml:"Placemark"`
+	Folder    []kmlDocument  `xml:"Folder"`
+}
+
+type kmlPlacemark struct {
+	Name          string           `xml:"name"`
+	ExtendedData  *kmlExtendedData `xml:"ExtendedData,omitempty"`
+	LineString    *kmlLineString   `xml:"LineString,omitempty"`
+	Polygon       *kmlPolygon      `xml:"Polygon,omitempty"`
+	Point         *struct{}        `xml:"Point,omitempty"`
+	MultiGeometry *struct{}        `xml:"MultiGeometry,omitempty"`
+}
+
+type kmlLineString struct {
+	AltitudeMode string `xml:"altitudeMode,omitempty"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+// KML encodes a track as a KML line string
+// with absolute altitudes
+func KML(name string, track []models.Position) ([]byte, error) {
+	coords := []string{}
+	for _, pos := range track {
+		coords = append(coords, kmlCoordinates(pos.Lon, pos.Lat, pos.Alt))
+	}
+
+	doc := kmlDoc{
+		Xmlns: "http://www.opengis.net/kml/2.2",
+		Document: kmlDocument{
+			Name: name,
+			Placemark: []kmlPlacemark{{
+				Name: name,
+				LineString: &kmlLineString{
+					AltitudeMode: "absolute",
+					Coordinates:  strings.Join(coords, " "),
+				},
+			}},
+		},
+	}
+
+	return encodeXML(doc)
+}
+
+func kmlCoordinates(lon, lat, alt float64) string {
+	return fmt.Sprintf("%v,%v,%v", lon, lat, alt)
+}
+
+type kmlPolygon struct {
+	Outer kmlBoundary   `xml:"outerBoundaryIs"`
+	Inner []kmlBoundary `xml:"innerBoundaryIs,omitempty"`
+}
+
+type kmlBoundary struct {
+	Coordinates string `xml:"LinearRing>coordinates"`
+}
+
+type kmlExtendedData struct {
+	Data []kmlData `xml:"Data"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+// ImportKML reads the placemarks of a KML file: polygons make the fence,
+// or the no fly zones when their "zone" data is "nofly", a line string
+// makes the route, floor, ceiling and speed are read from the extended data
+func ImportKML(data []byte) (*Plan, error) {
+	var doc kmlDoc
+	err := xml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	placemarks := append(doc.Placemark, doc.Document.placemarks()...)
+
+	plan := &Plan{}
+	errs := FeatureErrors{}
+
+	for i, p := range placemarks {
+		feature := fmt.Sprintf("placemark %d", i)
+		if p.Name != "" {
+			feature = fmt.Sprintf("placemark %d '%s'", i, p.Name)
+		}
+
+		switch {
+		case p.Polygon != nil:
+			if len(p.Polygon.Inner) > 0 {
+				errs.add(feature, "polygons with holes are not supported")
+				continue
+			}
+
+			points, err := parseKMLCoordinates(p.Polygon.Outer.Coordinates)
+			if err != nil {
+				errs.add(feature, "%v", err)
+				continue
+			}
+			// the first point of a KML ring is repeated at its end
+			if len(points) > 1 && points[0] == points[len(points)-1] {
+				points = points[:len(points)-1]
+			}
+
+			zone := models.ZoneData{Ceiling: defaultCeiling}
+			for _, point := range points {
+				zone.Points = append(zone.Points, models.PointData{Lat: point.Lat, Lon: point.Lon})
+			}
+			if floor, ok := p.number("floor"); ok {
+				zone.Floor = floor
+			}
+			if ceiling, ok := p.number("ceiling"); ok {
+				zone.Ceiling = ceiling
+			}
+
+			plan.addZone(&errs, feature, zone, p.data("zone") == "nofly")
+		case p.LineString != nil:
+			if p.LineString.AltitudeMode == "absolute" {
+				errs.add(feature, "absolute altitudes are not supported")
+				continue
+			}
+
+			points, err := parseKMLCoordinates(p.LineString.Coordinates)
+			if err != nil {
+				errs.add(feature, "%v", err)
+				continue
+			}
+
+			speed, _ := p.number("speed")
+			waypoints := []models.Waypoint{}
+			for _, point := range points {
+				waypoints = append(waypoints, models.Waypoint{Lat: point.Lat, Lon: point.Lon, RelAlt: point.Alt, Speed: speed})
+			}
+
+			plan.setRoute(&errs, feature, p.Name, waypoints)
+		case p.Point != nil:
+			errs.add(feature, "points are not supported")
+		case p.MultiGeometry != nil:
+			errs.add(feature, "multi geometries are not supported")
+		default:
+			errs.add(feature, "no geometry")
+		}
+	}
+
+	return plan.result(errs)
+}
+
+// ExportKML writes a plan as KML placemarks, the route
+// altitudes are relative to the launch point
+func ExportKML(plan Plan) ([]byte, error) {
+	doc := kmlDoc{
+		Xmlns:    "http://www.opengis.net/kml/2.2",
+		Document: kmlDocument{Name: creator},
+	}
+
+	if plan.Fence != nil {
+		for i, zone := range plan.Fence.Zones {
+			doc.Document.Placemark = append(doc.Document.Placemark, kmlZone(fmt.Sprintf("fence %d", i), zone, "fence"))
+		}
+	}
+	for i, zone := range plan.NoFly {
+		doc.Document.Placemark = append(doc.Document.Placemark, kmlZone(fmt.Sprintf("no fly zone %d", i), zone, "nofly"))
+	}
+
+	if plan.Mission != nil {
+		doc.Document.Name = plan.Mission.Name
+
+		coords := []string{}
+		for _, w := range plan.Mission.Waypoints {
+			coords = append(coords, kmlCoordinates(w.Lon, w.Lat, w.RelAlt))
+		}
+
+		doc.Document.Placemark = append(doc.Document.Placemark, kmlPlacemark{
+			Name: plan.Mission.Name,
+			LineString: &kmlLineString{
+				AltitudeMode: "relativeToGround",
+				Coordinates:  strings.Join(coords, " "),
+			},
+		})
+	}
+
+	return encodeXML(doc)
+}
+
+func kmlZone(name string, zone models.ZoneData, zoneType string) kmlPlacemark {
+	coords := []string{}
+	for _, p := range zone.Points {
+		coords = append(coords, kmlCoordinates(p.Lon, p.Lat, 0))
+	}
+	if len(zone.Points) > 0 {
+		coords = append(coords, coords[0])
+	}
+
+	return kmlPlacemark{
+		Name: name,
+		ExtendedData: &kmlExtendedData{Data: []kmlData{
+			{Name: "zone", Value: zoneType},
+			{Name: "floor", Value: fmt.Sprint(zone.Floor)},
+			{Name: "ceiling", Value: fmt.Sprint(zone.Ceiling)},
+		}},
+		Polygon: &kmlPolygon{
+			Outer: kmlBoundary{Coordinates: strings.Join(coords, " ")},
+		},
+	}
+}
+
+// placemarks returns the placemarks of a document and of its folders
+func (d kmlDocument) placemarks() []kmlPlacemark {
+	list := d.Placemark
+	for _, folder := range d.Folder {
+		list = append(list, folder.placemarks()...)
+	}
+
+	return list
+}
+
+// data returns the value of an extended data of the placemark
+func (p kmlPlacemark) data(name string) string {
+	if p.ExtendedData == nil {
+		return ""
+	}
+
+	for _, d := range p.ExtendedData.Data {
+		if d.Name == name {
+			return strings.TrimSpace(d.Value)
+		}
+	}
+
+	return ""
+}
+
+// number returns the value of a numeric extended data of the placemark
+func (p kmlPlacemark) number(name string) (float64, bool) {
+	val, err := strconv.ParseFloat(p.data(name), 64)
+	return val, err == nil
+}
+
+// parseKMLCoordinates parses a list of lon,lat[,alt] tuples
+func parseKMLCoordinates(coords string) ([]models.PointData, error) {
+	points := []models.PointData{}
+	for _, tuple := range strings.Fields(coords) {
+		values := strings.Split(tuple, ",")
+		if len(values) < 2 || len(values) > 3 {
+			return nil, fmt.Errorf("bad coordinates '%s'", tuple)
+		}
+
+		var nums []float64
+		for _, v := range values {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad coordinates '%s'", tuple)
+			}
+			nums = append(nums, n)
+		}
+
+		p := models.PointData{Lon: nums[0], Lat: nums[1]}
+		if len(nums) == 3 {
+			p.Alt = nums[2]
+		}
+		points = append(points, p)
+	}
+
+	return points, nil
+}
@@ -0,0 +1,134 @@
+package formats
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/volons/hive/models"
+)
+
+// Plan is the mission and the zones read from or written to a planning file
+type Plan struct {
+	Mission *models.Mission   `json:"mission,omitempty"`
+	Fence   *models.FenceData `json:"fence,omitempty"`
+	NoFly   models.NoFlyData  `json:"nofly,omitempty"`
+}
+
+// FeatureError reports a feature of an imported file that was rejected
+type FeatureError struct {
+	Feature string `json:"feature"`
+	Message string `json:"message"`
+}
+
+func (e FeatureError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Feature, e.Message)
+}
+
+// FeatureErrors is the list of features rejected in an imported file
+type FeatureErrors []FeatureError
+
+func (errs FeatureErrors) Error() string {
+	msgs := []string{}
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, ", ")
+}
+
+// add appends an error on a feature
+func (errs *FeatureErrors) add(feature string, format string, args ...interface{}) {
+	*errs = append(*errs, FeatureError{feature, fmt.Sprintf(format, args...)})
+}
+
+// defaultCeiling is the ceiling in meters of zones
+// imported from formats that have no altitude
+var defaultCeiling = float64(120)
+
+// Plan formats
+const (
+	FormatQGC     = "plan"
+	FormatGeoJSON = "geojson"
+	FormatKML     = "kml"
+)
+
+// Import reads a plan from a file in one of the plan formats,
+// the rejected features are returned as FeatureErrors
+func Import(format string, data []byte) (*Plan, error) {
+	switch format {
+	case FormatQGC:
+		return ImportQGC(data)
+	case FormatGeoJSON:
+		return ImportGeoJSON(data)
+	case FormatKML:
+		return ImportKML(data)
+	}
+
+	return nil, fmt.Errorf("unknown format '%s'", format)
+}
+
+// Export writes a plan to one of the plan formats
+func Export(format string, plan Plan) ([]byte, error) {
+	switch format {
+	case FormatQGC:
+		return ExportQGC(plan)
+	case FormatGeoJSON:
+		return ExportGeoJSON(plan)
+	case FormatKML:
+		return ExportKML(plan)
+	}
+
+	return nil, fmt.Errorf("unknown format '%s'", format)
+}
+
+// addZone checks an imported zone and adds it to the
+// fence or to the no fly zones of the plan
+func (p *Plan) addZone(errs *FeatureErrors, feature string, zone models.ZoneData, noFly bool) {
+	if _, err := models.NewZone(zone); err != nil {
+		errs.add(feature, "%v", err)
+		return
+	}
+
+	if noFly {
+		p.NoFly = append(p.NoFly, zone)
+		return
+	}
+
+	if p.Fence == nil {
+		p.Fence = &models.FenceData{}
+	}
+	p.Fence.Zones = append(p.Fence.Zones, zone)
+}
+
+// setRoute checks imported waypoints and sets them as the mission of the plan
+func (p *Plan) setRoute(errs *FeatureErrors, feature string, name string, waypoints []models.Waypoint) {
+	if p.Mission != nil {
+		errs.add(feature, "a file can only contain one route")
+		return
+	}
+	if len(waypoints) == 0 {
+		errs.add(feature, "route has no waypoints")
+		return
+	}
+
+	for i, w := range waypoints {
+		if err := w.Validate(); err != nil {
+			errs.add(feature, "waypoint %d: %v", i, err)
+			return
+		}
+	}
+
+	p.Mission = &models.Mission{Name: name, Waypoints: waypoints}
+}
+
+// result returns the imported plan or the rejected features
+func (p *Plan) result(errs FeatureErrors) (*Plan, error) {
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if p.Mission == nil && p.Fence == nil && len(p.NoFly) == 0 {
+		return nil, FeatureErrors{{"file", "no route or zone found"}}
+	}
+
+	return p, nil
+}
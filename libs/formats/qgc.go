@@ -0,0 +1,236 @@
+package formats
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/volons/hive/models"
+)
+
+// MAVLink commands and frames used in QGroundControl plans
+const (
+	qgcNavWaypoint     = 16
+	qgcNavTakeoff      = 22
+	qgcDoChangeSpeed   = 178
+	qgcDoMountControl  = 205
+	qgcFrameMission    = 2
+	qgcFrameRelative   = 3
+	qgcFirmwareGeneric = 0
+	qgcVehicleQuadRtr  = 2
+)
+
+type qgcPlan struct {
+	FileType      string      `json:"fileType"`
+	Version       int         `json:"version"`
+	GroundStation string      `json:"groundStation"`
+	Mission       qgcMission  `json:"mission"`
+	GeoFence      qgcGeoFence `json:"geoFence"`
+	RallyPoints   qgcRally    `json:"rallyPoints"`
+}
+
+type qgcMission struct {
+	Version             int       `json:"version"`
+	FirmwareType        int       `json:"firmwareType"`
+	VehicleType         int       `json:"vehicleType"`
+	CruiseSpeed         float64   `json:"cruiseSpeed"`
+	HoverSpeed          float64   `json:"hoverSpeed"`
+	PlannedHomePosition []float64 `json:"plannedHomePosition"`
+	Items               []qgcItem `json:"items"`
+}
+
+type qgcItem struct {
+	Type         string     `json:"type"`
+	ComplexType  string     `json:"complexItemType,omitempty"`
+	Command      int        `json:"command"`
+	Frame        int        `json:"frame"`
+	Params       []*float64 `json:"params"`
+	AutoContinue bool       `json:"autoContinue"`
+	DoJumpID     int        `json:"doJumpId"`
+}
+
+type qgcGeoFence struct {
+	Version  int          `json:"version"`
+	Polygons []qgcPolygon `json:"polygons"`
+	Circles  []struct{}   `json:"circles"`
+}
+
+type qgcPolygon struct {
+	Version   int          `json:"version"`
+	Inclusion bool         `json:"inclusion"`
+	Polygon   [][2]float64 `json:"polygon"`
+}
+
+type qgcRally struct {
+	Version int          `json:"version"`
+	Points  [][3]float64 `json:"points"`
+}
+
+// ImportQGC reads a QGroundControl .plan file, inclusion polygons
+// make the fence and exclusion polygons the no fly zones
+func ImportQGC(data []byte) (*Plan, error) {
+	var doc qgcPlan
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+	if doc.FileType != "Plan" {
+		return nil, fmt.Errorf("unknown file type '%s'", doc.FileType)
+	}
+
+	plan := &Plan{}
+	errs := FeatureErrors{}
+
+	if len(doc.Mission.Items) > 0 {
+		waypoints := qgcWaypoints(&errs, doc.Mission.Items)
+		if len(errs) == 0 {
+			plan.setRoute(&errs, "mission", "", waypoints)
+		}
+	}
+
+	for i, p := range doc.GeoFence.Polygons {
+		zone := models.ZoneData{Ceiling: defaultCeiling}
+		for _, point := range p.Polygon {
+			zone.Points = append(zone.Points, models.PointData{Lat: point[0], Lon: point[1]})
+		}
+
+		plan.addZone(&errs, fmt.Sprintf("geoFence polygon %d", i), zone, !p.Inclusion)
+	}
+	for i := range doc.GeoFence.Circles {
+		errs.add(fmt.Sprintf("geoFence circle %d", i), "circles are not supported")
+	}
+
+	return plan.result(errs)
+}
+
+// qgcWaypoints converts mission items to waypoints, speed changes apply
+// to the following waypoints and gimbal commands to the previous one
+func qgcWaypoints(errs *FeatureErrors, items []qgcItem) []models.Waypoint {
+	waypoints := []models.Waypoint{}
+	speed := float64(0)
+
+	for i, item := range items {
+		feature := fmt.Sprintf("mission item %d", i)
+		if item.Type != "SimpleItem" {
+			errs.add(feature, "%s items are not supported", item.ComplexType)
+			continue
+		}
+
+		param := func(n int) float64 {
+			if n < len(item.Params) && item.Params[n] != nil {
+				return *item.Params[n]
+			}
+			return 0
+		}
+
+		switch item.Command {
+		case qgcNavTakeoff:
+			// vehicles take off on their own before a mission starts
+		case qgcNavWaypoint:
+			if item.Frame != qgcFrameRelative {
+				errs.add(feature, "frame %d is not supported, altitudes should be relative", item.Frame)
+				continue
+			}
+			waypoints = append(waypoints, models.Waypoint{
+				Lat:    param(4),
+				Lon:    param(5),
+				RelAlt: param(6),
+				Speed:  speed,
+				Hold:   param(0),
+			})
+		case qgcDoChangeSpeed:
+			speed = param(1)
+		case qgcDoMountControl:
+			if len(waypoints) == 0 {
+				errs.add(feature, "gimbal command before the first waypoint")
+				continue
+			}
+			pitch := param(0)
+			waypoints[len(waypoints)-1].Gimbal = &pitch
+		default:
+			errs.add(feature, "command %d is not supported", item.Command)
+		}
+	}
+
+	return waypoints
+}
+
+// ExportQGC writes a plan as a QGroundControl .plan file
+func ExportQGC(plan Plan) ([]byte, error) {
+	doc := qgcPlan{
+		FileType:      "Plan",
+		Version:       1,
+		GroundStation: creator,
+		Mission: qgcMission{
+			Version:      2,
+			FirmwareType: qgcFirmwareGeneric,
+			VehicleType:  qgcVehicleQuadRtr,
+			Items:        []qgcItem{},
+		},
+		GeoFence: qgcGeoFence{
+			Version:  2,
+			Polygons: []qgcPolygon{},
+			Circles:  []struct{}{},
+		},
+		RallyPoints: qgcRally{Version: 2, Points: [][3]float64{}},
+	}
+
+	if plan.Mission != nil && len(plan.Mission.Waypoints) > 0 {
+		first := plan.Mission.Waypoints[0]
+		doc.Mission.PlannedHomePosition = []float64{first.Lat, first.Lon, 0}
+
+		speed := float64(0)
+		for _, w := range plan.Mission.Waypoints {
+			if w.Speed != speed {
+				speed = w.Speed
+				doc.Mission.Items = append(doc.Mission.Items, qgcCommand(qgcDoChangeSpeed, qgcFrameMission, 1, speed, -1, 0))
+			}
+
+			doc.Mission.Items = append(doc.Mission.Items, qgcCommand(qgcNavWaypoint, qgcFrameRelative, w.Hold, 0, 0, 0, w.Lat, w.Lon, w.RelAlt))
+
+			if w.Gimbal != nil {
+				doc.Mission.Items = append(doc.Mission.Items, qgcCommand(qgcDoMountControl, qgcFrameMission, *w.Gimbal, 0, 0))
+			}
+		}
+
+		for i := range doc.Mission.Items {
+			doc.Mission.Items[i].DoJumpID = i + 1
+		}
+	}
+
+	if plan.Fence != nil {
+		for _, zone := range plan.Fence.Zones {
+			doc.GeoFence.Polygons = append(doc.GeoFence.Polygons, qgcZone(zone, true))
+		}
+	}
+	for _, zone := range plan.NoFly {
+		doc.GeoFence.Polygons = append(doc.GeoFence.Polygons, qgcZone(zone, false))
+	}
+
+	return json.MarshalIndent(doc, "", "    ")
+}
+
+// qgcCommand creates a mission item, missing params are null
+func qgcCommand(command, frame int, params ...float64) qgcItem {
+	item := qgcItem{
+		Type:         "SimpleItem",
+		Command:      command,
+		Frame:        frame,
+		Params:       make([]*float64, 7),
+		AutoContinue: true,
+	}
+
+	for i := range params {
+		item.Params[i] = &params[i]
+	}
+
+	return item
+}
+
+func qgcZone(zone models.ZoneData, inclusion bool) qgcPolygon {
+	p := qgcPolygon{Version: 1, Inclusion: inclusion}
+	for _, point := range zone.Points {
+		p.Polygon = append(p.Polygon, [2]float64{point.Lat, point.Lon})
+	}
+
+	return p
+}
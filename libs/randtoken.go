@@ -1,6 +1,7 @@
 package libs
 
 import (
+	crand "crypto/rand"
 	"fmt"
 	"math/rand"
 	"time"
@@ -12,3 +13,15 @@ func RandToken(len int) string {
 	rand.Read(b)
 	return fmt.Sprintf("%x", b)
 }
+
+// SecureToken returns a random hex token of len bytes
+// suitable for credentials
+func SecureToken(len int) (string, error) {
+	b := make([]byte, len)
+	_, err := crand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", b), nil
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/db"
+	"github.com/volons/hive/models"
+)
+
+type admins struct {
+	// connected admins, one entry per connection
+	connected *sync.Map
+}
+
+func newAdmins() admins {
+	return admins{
+		connected: &sync.Map{},
+	}
+}
+
+// Create creates and saves a new admin account,
+// returns the admin and its api key
+func (a admins) Create(name string, role models.Role, vehicles []string) (*models.Admin, string, error) {
+	if name == "" {
+		return nil, "", errors.New("need name")
+	}
+	if !role.Valid() {
+		return nil, "", fmt.Errorf("unknown role '%s'", role)
+	}
+
+	key, err := libs.SecureToken(24)
+	if err != nil {
+		return nil, "", err
+	}
+
+	id, err := libs.SecureToken(8)
+	if err != nil {
+		return nil, "", err
+	}
+	if a.Get(id) != nil {
+		return nil, "", fmt.Errorf("admin with ID '%s' already exists", id)
+	}
+
+	admin := models.NewAdmin(id, name, role, vehicles)
+	admin.SetKey(key)
+
+	err = a.Save(admin)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return admin, key, nil
+}
+
+// Save saves an admin account to db
+func (a admins) Save(admin *models.Admin) error {
+	return db.Set(a.adminKey(admin.ID()), admin)
+}
+
+// Get returns an admin account by ID
+func (a admins) Get(id string) *models.Admin {
+	admin := &models.Admin{}
+	err := db.Get(a.adminKey(id), admin)
+	if err != nil {
+		return nil
+	}
+
+	return admin
+}
+
+// SetAccess changes the role of an admin account and the vehicles it has
+// access to, connected admins get their new rights right away
+func (a admins) SetAccess(id string, role models.Role, vehicles []string) (*models.Admin, error) {
+	if !role.Valid() {
+		return nil, fmt.Errorf("unknown role '%s'", role)
+	}
+
+	admin := a.Get(id)
+	if admin == nil {
+		return nil, fmt.Errorf("unknown admin with ID '%s'", id)
+	}
+
+	admin.SetAccess(role, vehicles)
+	err := a.Save(admin)
+	if err != nil {
+		return nil, err
+	}
+
+	a.connected.Range(func(k interface{}, val interface{}) bool {
+		connected := val.(*models.Admin)
+		if connected.ID() == id {
+			connected.SetAccess(role, vehicles)
+		}
+		return true
+	})
+
+	return admin, nil
+}
+
+// Revoke deletes an admin account and disconnects it
+func (a admins) Revoke(id string) error {
+	if a.Get(id) == nil {
+		return fmt.Errorf("unknown admin with ID '%s'", id)
+	}
+
+	err := db.Delete(a.adminKey(id))
+	if err != nil {
+		return err
+	}
+
+	a.connected.Range(func(k interface{}, val interface{}) bool {
+		admin := val.(*models.Admin)
+		if admin.ID() == id {
+			admin.Close()
+		}
+		return true
+	})
+
+	return nil
+}
+
+// Authenticate retreives an admin account from an api key
+func (a admins) Authenticate(apiKey string) (*models.Admin, error) {
+	if apiKey == "" {
+		return nil, errors.New("Need a token")
+	}
+
+	for _, admin := range a.list() {
+		if admin.CheckKey(apiKey) {
+			return admin, nil
+		}
+	}
+
+	return nil, errors.New("Invalid token")
+}
+
+// Connected registers a connected admin so that
+// it can be disconnected when revoked
+func (a admins) Connected(admin *models.Admin) {
+	a.connected.Store(admin, admin)
+}
+
+// Disconnected unregisters a connected admin
+func (a admins) Disconnected(admin *models.Admin) {
+	a.connected.Delete(admin)
+}
+
+// Length returns the number of admin accounts
+func (a admins) Length() int {
+	keys, err := db.Find(adminPrefix)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return len(keys)
+}
+
+// JSON returns the list of admin accounts in a json serializable format
+func (a admins) JSON() []models.AdminInfo {
+	list := []models.AdminInfo{}
+	for _, admin := range a.list() {
+		list = append(list, admin.Info())
+	}
+
+	return list
+}
+
+func (a admins) list() []*models.Admin {
+	keys, err := db.Find(adminPrefix)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	var list []*models.Admin
+	for _, key := range keys {
+		admin := &models.Admin{}
+		err := db.Get(key, admin)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		list = append(list, admin)
+	}
+
+	return list
+}
+
+var adminPrefix = "admin:account:"
+
+func (a admins) adminKey(id string) string {
+	return fmt.Sprintf("%s%s", adminPrefix, id)
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/volons/hive/libs/callback"
+	"github.com/volons/hive/libs/db"
+	"github.com/volons/hive/models"
+)
+
+type audit struct {
+	seq *uint64
+}
+
+func newAudit() audit {
+	return audit{
+		seq: new(uint64),
+	}
+}
+
+// Add appends an entry to the audit log
+func (a audit) Add(entry models.AuditEntry) {
+	err := db.Set(a.entryKey(entry), entry)
+	if err != nil {
+		log.Println("Could not save audit entry", err)
+	}
+}
+
+// Record appends an entry to the audit log once the reply
+// to the audited message is known, cb can be nil
+func (a audit) Record(entry models.AuditEntry, cb *callback.Callback) {
+	if cb == nil {
+		a.Add(entry)
+		return
+	}
+
+	cb.Listen(func(res interface{}, err error) {
+		entry.SetResult(res, err)
+		a.Add(entry)
+	})
+}
+
+// Find returns the audit entries recorded between from and to,
+// for a single vehicle if vehicleID is not empty
+func (a audit) Find(vehicleID string, from, to time.Time) ([]models.AuditEntry, error) {
+	keys, err := db.Find(auditPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []models.AuditEntry{}
+	for _, key := range keys {
+		var entry models.AuditEntry
+		err := db.Get(key, &entry)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if vehicleID != "" && entry.VehicleID != vehicleID {
+			continue
+		}
+
+		if inRange(entry.Timestamp, from, to) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+var auditPrefix = "audit:"
+
+func (a audit) entryKey(entry models.AuditEntry) string {
+	seq := atomic.AddUint64(a.seq, 1)
+	return fmt.Sprintf("%s%020d:%d", auditPrefix, entry.Timestamp.UnixNano(), seq)
+}
@@ -0,0 +1,21 @@
+package store
+
+import (
+	"github.com/volons/hive/libs/pubsub"
+	"github.com/volons/hive/models"
+)
+
+type events struct {
+	*pubsub.Topic
+}
+
+func newEvents() *events {
+	return &events{
+		Topic: pubsub.NewTopic(),
+	}
+}
+
+// Add publishes an event to the admins
+func (e *events) Add(event models.Event) {
+	e.Publish(event)
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/volons/hive/libs/db"
+	"github.com/volons/hive/libs/pubsub"
+	"github.com/volons/hive/models"
+)
+
+type fenceEvents struct {
+	*pubsub.Topic
+}
+
+func newFenceEvents() *fenceEvents {
+	return &fenceEvents{
+		Topic: pubsub.NewTopic(),
+	}
+}
+
+// Add saves a fence event to db and publishes it
+func (f *fenceEvents) Add(event models.FenceEvent) {
+	err := db.Set(f.eventKey(event), event)
+	if err != nil {
+		log.Println("Could not save fence event", err)
+	}
+
+	f.Publish(event)
+}
+
+// Find returns the fence events recorded between from and to,
+// for a single vehicle if vehicleID is not empty
+func (f *fenceEvents) Find(vehicleID string, from, to time.Time) ([]models.FenceEvent, error) {
+	prefix := fenceEventPrefix
+	if vehicleID != "" {
+		prefix = fmt.Sprintf("%s%s:", fenceEventPrefix, vehicleID)
+	}
+
+	keys, err := db.Find(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []models.FenceEvent{}
+	for _, key := range keys {
+		var event models.FenceEvent
+		err := db.Get(key, &event)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if inRange(event.Timestamp, from, to) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+var fenceEventPrefix = "fence:event:"
+
+func (f *fenceEvents) eventKey(event models.FenceEvent) string {
+	return fmt.Sprintf("%s%s:%020d", fenceEventPrefix, event.VehicleID, event.Timestamp.UnixNano())
+}
+
+// inRange checks if t is between from and to,
+// a zero from or to is not taken into account
+func inRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && t.After(to) {
+		return false
+	}
+
+	return true
+}
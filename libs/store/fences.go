@@ -0,0 +1,135 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/volons/hive/libs/db"
+	"github.com/volons/hive/models"
+)
+
+type fences struct{}
+
+func newFences() fences {
+	return fences{}
+}
+
+// Load sets the fence of every vehicle saved in the db
+func (f fences) Load() {
+	f.migrate()
+
+	keys, err := db.Find(fencePrefix)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, key := range keys {
+		vehicleID := key[len(fencePrefix):]
+
+		var fence models.FenceData
+		err := db.Get(key, &fence)
+		if err != nil {
+			log.Printf("Cannot get fence of vehicle '%v': %v", vehicleID, err)
+			continue
+		}
+
+		err = models.SetFence(vehicleID, fence)
+		if err != nil {
+			log.Printf("Could not set fence of vehicle '%v': %v", vehicleID, err)
+		}
+	}
+}
+
+// migrate gives the fence shared by every vehicle in previous
+// versions to the known vehicles which have no fence of their own
+func (f fences) migrate() {
+	var fence models.FenceData
+	err := db.Get(legacyFenceKey, &fence)
+	if err != nil {
+		if !db.IsNotFoudError(err) {
+			log.Printf("Cannot get legacy fence: %v", err)
+		}
+		return
+	}
+
+	vehicleIDs := Vehicles.GetIDs()
+	if len(vehicleIDs) == 0 {
+		log.Println("Legacy fence kept until a vehicle is known")
+		return
+	}
+
+	for _, vehicleID := range vehicleIDs {
+		var existing models.FenceData
+		if db.Get(f.fenceKey(vehicleID), &existing) == nil {
+			continue
+		}
+
+		err := db.Set(f.fenceKey(vehicleID), fence)
+		if err != nil {
+			log.Printf("Could not migrate fence of vehicle '%v': %v", vehicleID, err)
+			return
+		}
+	}
+
+	err = db.Delete(legacyFenceKey)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// Set sets the fence of a vehicle and saves it to db
+func (f fences) Set(vehicleID string, fence models.FenceData) error {
+	err := models.SetFence(vehicleID, fence)
+	if err != nil {
+		return err
+	}
+
+	return db.Set(f.fenceKey(vehicleID), fence)
+}
+
+// SetParams changes the tuning parameters of
+// the fence of a vehicle and saves it to db
+func (f fences) SetParams(vehicleID string, params models.FenceParams) error {
+	fence := models.GetFence(vehicleID)
+	if fence == nil {
+		return errors.New("Fence is not set")
+	}
+
+	data := fence.JSON()
+	data.Params = &params
+
+	return f.Set(vehicleID, data)
+}
+
+// Clear removes the fence of a vehicle from db
+// returns true if the vehicle had a fence
+func (f fences) Clear(vehicleID string) (bool, error) {
+	cleared := models.ClearFence(vehicleID)
+
+	err := db.Delete(f.fenceKey(vehicleID))
+	if err != nil {
+		return cleared, err
+	}
+
+	return cleared, nil
+}
+
+// JSON returns the fence of every vehicle in a json serializable format
+func (f fences) JSON() map[string]models.FenceData {
+	out := make(map[string]models.FenceData)
+	for vehicleID, fence := range models.GetFences() {
+		out[vehicleID] = fence.JSON()
+	}
+
+	return out
+}
+
+var fencePrefix = "fence:vehicle:"
+
+var legacyFenceKey = "fence"
+
+func (f fences) fenceKey(vehicleID string) string {
+	return fmt.Sprintf("%s%s", fencePrefix, vehicleID)
+}
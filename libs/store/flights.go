@@ -0,0 +1,149 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/volons/hive/libs/db"
+	"github.com/volons/hive/models"
+)
+
+type flights struct{}
+
+func newFlights() flights {
+	return flights{}
+}
+
+// Add attaches the fence events recorded during
+// a finished flight to it and saves it to db
+func (f flights) Add(flight *models.Flight) {
+	events, err := FenceEvents.Find(flight.VehicleID, flight.Start, flight.End)
+	if err != nil {
+		log.Println(err)
+	} else {
+		flight.FenceEvents = events
+	}
+
+	err = db.Set(f.flightKey(flight.VehicleID, flight.ID), flight)
+	if err != nil {
+		log.Println("Could not save flight", err)
+		return
+	}
+
+	// a new flight may already be open
+	if open := f.open(flight.VehicleID); open != nil && open.ID == flight.ID {
+		err = db.Delete(f.openKey(flight.VehicleID))
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// SetOpen saves a running flight
+func (f flights) SetOpen(flight *models.Flight) error {
+	return db.Set(f.openKey(flight.VehicleID), flight)
+}
+
+// CloseOpen saves the flights left open by a previous run,
+// they end when their vehicle was last seen
+func (f flights) CloseOpen() {
+	keys, err := db.Find(openFlightPrefix)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, key := range keys {
+		flight := f.open(key[len(openFlightPrefix):])
+		if flight == nil {
+			continue
+		}
+
+		flight.End = flight.Start
+		var lastSeen time.Time
+		if db.Get(Vehicles.lastSeenKey(flight.VehicleID), &lastSeen) == nil && lastSeen.After(flight.Start) {
+			flight.End = lastSeen
+		}
+
+		f.Add(flight)
+	}
+}
+
+func (f flights) open(vehicleID string) *models.Flight {
+	flight := &models.Flight{}
+	err := db.Get(f.openKey(vehicleID), flight)
+	if err != nil {
+		return nil
+	}
+
+	return flight
+}
+
+// Get returns a flight of a vehicle by ID
+func (f flights) Get(vehicleID, id string) *models.Flight {
+	flight := &models.Flight{}
+	err := db.Get(f.flightKey(vehicleID, id), flight)
+	if err != nil {
+		return nil
+	}
+
+	return flight
+}
+
+// Find returns the flights started between from and to,
+// of a single vehicle if vehicleID is not empty
+func (f flights) Find(vehicleID string, from, to time.Time) ([]models.Flight, error) {
+	prefix := flightPrefix
+	if vehicleID != "" {
+		prefix = fmt.Sprintf("%s%s:", flightPrefix, vehicleID)
+	}
+
+	keys, err := db.Find(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []models.Flight{}
+	for _, key := range keys {
+		var flight models.Flight
+		err := db.Get(key, &flight)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if inRange(flight.Start, from, to) {
+			list = append(list, flight)
+		}
+	}
+
+	return list, nil
+}
+
+// Track returns the recorded positions of a flight
+func (f flights) Track(flight *models.Flight) ([]models.Position, error) {
+	samples, err := History.Find(flight.VehicleID, models.SamplePosition, flight.Start, flight.End)
+	if err != nil {
+		return nil, err
+	}
+
+	track := []models.Position{}
+	for _, sample := range samples {
+		track = append(track, *sample.Position)
+	}
+
+	return track, nil
+}
+
+var flightPrefix = "flight:"
+
+var openFlightPrefix = "openflight:"
+
+func (f flights) openKey(vehicleID string) string {
+	return fmt.Sprintf("%s%s", openFlightPrefix, vehicleID)
+}
+
+func (f flights) flightKey(vehicleID, id string) string {
+	return fmt.Sprintf("%s%s:%020s", flightPrefix, vehicleID, id)
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/volons/hive/libs/db"
+	"github.com/volons/hive/models"
+	"github.com/volons/hive/models/config"
+)
+
+// historyInterval is the minimum time between two samples
+// of the same kind kept for a vehicle
+var historyInterval = time.Second
+
+type history struct {
+	last *sync.Map // last sample kept by vehicle ID and kind
+}
+
+func newHistory() history {
+	return history{
+		last: &sync.Map{},
+	}
+}
+
+// Add appends a telemetry sample to the history of a vehicle, one sample
+// per kind and interval is kept, samples expire after the configured retention time
+func (h history) Add(vehicleID string, sample models.TelemetrySample) {
+	if !h.keep(vehicleID, sample) {
+		return
+	}
+
+	err := db.SetWithTTL(h.sampleKey(vehicleID, sample), sample, config.Get().Retention())
+	if err != nil {
+		log.Println("Could not save telemetry sample", err)
+	}
+}
+
+// Find returns the telemetry samples of a vehicle recorded between
+// from and to sorted by time, only those of the given kind if not empty
+func (h history) Find(vehicleID string, kind string, from, to time.Time) ([]models.TelemetrySample, error) {
+	kinds := []string{models.SampleStatus, models.SamplePosition, models.SampleBattery}
+	if kind != "" {
+		kinds = []string{kind}
+	}
+
+	samples := []models.TelemetrySample{}
+	for _, kind := range kinds {
+		prefix := h.prefix(vehicleID, kind)
+		keys, err := db.Find(prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			// Skip samples out of range before reading them
+			ns, err := strconv.ParseInt(strings.TrimPrefix(key, prefix), 10, 64)
+			if err != nil || !inRange(time.Unix(0, ns), from, to) {
+				continue
+			}
+
+			var sample models.TelemetrySample
+			err = db.Get(key, &sample)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			samples = append(samples, sample)
+		}
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Timestamp.Before(samples[j].Timestamp)
+	})
+
+	return samples, nil
+}
+
+// keep samples the telemetry, vehicles send it faster than tracks need
+// and writing every message would load the db, status changes are always kept
+func (h history) keep(vehicleID string, sample models.TelemetrySample) bool {
+	k := h.prefix(vehicleID, sample.Kind())
+	if val, ok := h.last.Load(k); ok {
+		last := val.(models.TelemetrySample)
+		changed := sample.Status != nil && *sample.Status != *last.Status
+		if !changed && sample.Timestamp.Sub(last.Timestamp) < historyInterval {
+			return false
+		}
+	}
+
+	h.last.Store(k, sample)
+	return true
+}
+
+var historyPrefix = "history:"
+
+func (h history) prefix(vehicleID string, kind string) string {
+	return fmt.Sprintf("%s%s:%s:", historyPrefix, vehicleID, kind)
+}
+
+func (h history) sampleKey(vehicleID string, sample models.TelemetrySample) string {
+	return fmt.Sprintf("%s%020d", h.prefix(vehicleID, sample.Kind()), sample.Timestamp.UnixNano())
+}
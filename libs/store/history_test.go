@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/volons/hive/models"
+)
+
+func TestHistoryKeepsOneSamplePerInterval(t *testing.T) {
+	h := newHistory()
+	start := time.Now()
+
+	kept := 0
+	for i := 0; i < 50; i++ {
+		pos := models.NewPoint(48.85, 2.35, 10)
+		sample := models.TelemetrySample{Timestamp: start.Add(time.Duration(i) * 100 * time.Millisecond), Position: &pos}
+		if h.keep("vehicle", sample) {
+			kept++
+		}
+	}
+
+	if kept != 5 {
+		t.Errorf("%d positions kept out of 5s at 10Hz, should be 5", kept)
+	}
+}
+
+func TestHistoryKeepsStatusChanges(t *testing.T) {
+	h := newHistory()
+	start := time.Now()
+
+	for i, armed := range []bool{false, true, false} {
+		status := models.NewStatus(armed)
+		sample := models.TelemetrySample{Timestamp: start.Add(time.Duration(i) * time.Millisecond), Status: &status}
+		if !h.keep("vehicle", sample) {
+			t.Errorf("status change to armed %v not kept", armed)
+		}
+	}
+
+	status := models.NewStatus(false)
+	if h.keep("vehicle", models.TelemetrySample{Timestamp: start.Add(5 * time.Millisecond), Status: &status}) {
+		t.Error("unchanged status kept within the interval")
+	}
+}
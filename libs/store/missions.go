@@ -0,0 +1,157 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/db"
+	"github.com/volons/hive/models"
+)
+
+type missionPlans struct{}
+
+func newMissionPlans() missionPlans {
+	return missionPlans{}
+}
+
+// Validate checks a plan against the mission settings and
+// the fence of its vehicle and against the no fly zones
+func (m missionPlans) Validate(plan *models.MissionPlan) models.WaypointErrors {
+	settings := models.DefaultVehicleSettings()
+	var fence *models.Fence
+	if plan.VehicleID != "" {
+		settings = Vehicles.Settings(plan.VehicleID)
+		fence = models.GetFence(plan.VehicleID)
+	}
+
+	return plan.Check(settings.Mission, fence, models.GetNoFlyZones())
+}
+
+// Save validates and saves a plan as a new version, a plan
+// without ID is created, the validation errors are returned
+// as models.WaypointErrors
+func (m missionPlans) Save(plan *models.MissionPlan) error {
+	if errs := m.Validate(plan); len(errs) > 0 {
+		return errs
+	}
+
+	now := time.Now()
+	if plan.ID == "" {
+		plan.ID = libs.RandToken(6)
+		plan.Version = 1
+		plan.Created = now
+	} else {
+		latest := m.Get(plan.ID, 0)
+		if latest == nil {
+			return fmt.Errorf("unknown mission plan with ID '%s'", plan.ID)
+		}
+		plan.Version = latest.Version + 1
+		plan.Created = latest.Created
+	}
+	plan.Updated = now
+
+	return db.Set(m.planKey(plan.ID, plan.Version), plan)
+}
+
+// Get returns a version of a plan, 0 for the latest one
+func (m missionPlans) Get(id string, version int) *models.MissionPlan {
+	key := m.planKey(id, version)
+	if version == 0 {
+		keys, err := db.Find(m.planPrefix(id))
+		if err != nil || len(keys) == 0 {
+			return nil
+		}
+		key = keys[len(keys)-1]
+	}
+
+	plan := &models.MissionPlan{}
+	err := db.Get(key, plan)
+	if err != nil {
+		return nil
+	}
+
+	return plan
+}
+
+// Versions returns every version of a plan, oldest first
+func (m missionPlans) Versions(id string) ([]models.MissionPlan, error) {
+	keys, err := db.Find(m.planPrefix(id))
+	if err != nil {
+		return nil, err
+	}
+
+	return m.load(keys), nil
+}
+
+// List returns the latest version of every plan
+func (m missionPlans) List() ([]models.MissionPlan, error) {
+	keys, err := db.Find(missionPlanPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// keys are sorted, the last key of a plan is its latest version
+	latest := []string{}
+	for i, key := range keys {
+		if i+1 < len(keys) && m.planID(keys[i+1]) == m.planID(key) {
+			continue
+		}
+		latest = append(latest, key)
+	}
+
+	return m.load(latest), nil
+}
+
+// Delete deletes every version of a plan
+func (m missionPlans) Delete(id string) error {
+	keys, err := db.Find(m.planPrefix(id))
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("unknown mission plan with ID '%s'", id)
+	}
+
+	for _, key := range keys {
+		err := db.Delete(key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m missionPlans) load(keys []string) []models.MissionPlan {
+	list := []models.MissionPlan{}
+	for _, key := range keys {
+		var plan models.MissionPlan
+		err := db.Get(key, &plan)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		list = append(list, plan)
+	}
+
+	return list
+}
+
+var missionPlanPrefix = "mission:plan:"
+
+func (m missionPlans) planPrefix(id string) string {
+	return fmt.Sprintf("%s%s:", missionPlanPrefix, id)
+}
+
+func (m missionPlans) planKey(id string, version int) string {
+	return fmt.Sprintf("%s%010d", m.planPrefix(id), version)
+}
+
+func (m missionPlans) planID(key string) string {
+	id := strings.TrimPrefix(key, missionPlanPrefix)
+	return id[:strings.LastIndex(id, ":")]
+}
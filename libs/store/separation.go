@@ -0,0 +1,33 @@
+package store
+
+import (
+	"log"
+
+	"github.com/volons/hive/libs/db"
+	"github.com/volons/hive/models"
+)
+
+type separation struct{}
+
+func newSeparation() separation {
+	return separation{}
+}
+
+// Settings returns the separation settings or
+// the default settings if they were not configured
+func (s separation) Settings() models.SeparationSettings {
+	settings := models.DefaultSeparationSettings()
+	err := db.Get(separationSettingsKey, &settings)
+	if err != nil && !db.IsNotFoudError(err) {
+		log.Println(err)
+	}
+
+	return settings
+}
+
+// SetSettings saves the separation settings
+func (s separation) SetSettings(settings models.SeparationSettings) error {
+	return db.Set(separationSettingsKey, settings)
+}
+
+var separationSettingsKey = "separation:settings"
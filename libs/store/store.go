@@ -9,6 +9,33 @@ var Vehicles = newVehicleList()
 // Positions contains the list of vehicle positions
 var Positions = newPositionList()
 
+// History stores the telemetry received from every vehicle
+var History = newHistory()
+
+// Flights stores the flights of every vehicle
+var Flights = newFlights()
+
+// MissionPlans stores the mission plans prepared by admins
+var MissionPlans = newMissionPlans()
+
+// Fences stores the fence of each vehicle
+var Fences = newFences()
+
+// FenceEvents stores the fence breaches of every vehicle
+var FenceEvents = newFenceEvents()
+
+// Separation stores the minimum separation between vehicles
+var Separation = newSeparation()
+
+// Events notifies admins of what happens to vehicles
+var Events = newEvents()
+
+// Audit stores the commands sent to vehicles and by admins
+var Audit = newAudit()
+
+// Admins stores the admin accounts
+var Admins = newAdmins()
+
 //Queue stores the users waiting for their turn
 var Queue = newQueue()
 
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/volons/hive/libs/db"
+	"github.com/volons/hive/models"
+)
+
+// Telemetry is the last known state of a vehicle
+type Telemetry struct {
+	Status    models.Status   `json:"status"`
+	Position  models.Position `json:"position"`
+	Battery   models.Battery  `json:"battery"`
+	Timestamp time.Time       `json:"timestamp"`
+	Replay    bool            `json:"replay,omitempty"`
+}
+
+// NewTelemetry creates an empty telemetry
+func NewTelemetry() Telemetry {
+	return Telemetry{Timestamp: time.Now()}
+}
+
+// telemetryCache keeps the last telemetry of every vehicle in memory
+// and signals changes by closing its changed channel
+type telemetryCache struct {
+	lock    sync.RWMutex
+	data    map[string]Telemetry
+	changed chan bool
+}
+
+func newTelemetryCache() *telemetryCache {
+	return &telemetryCache{
+		data:    make(map[string]Telemetry),
+		changed: make(chan bool),
+	}
+}
+
+func (c *telemetryCache) update(vehicleID string, fn func(*Telemetry)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := c.data[vehicleID]
+	fn(&t)
+	t.Timestamp = time.Now()
+	c.data[vehicleID] = t
+
+	close(c.changed)
+	c.changed = make(chan bool)
+}
+
+// LoadTelemetry fills the telemetry cache with the
+// last telemetry of every vehicle saved in the db
+func (v vehicleList) LoadTelemetry() {
+	loadTelemetry(statusPrefix, func(t *Telemetry, key string) error { return db.Get(key, &t.Status) })
+	loadTelemetry(positionPrefix, func(t *Telemetry, key string) error { return db.Get(key, &t.Position) })
+	loadTelemetry(batteryPrefix, func(t *Telemetry, key string) error { return db.Get(key, &t.Battery) })
+}
+
+func loadTelemetry(prefix string, get func(*Telemetry, string) error) {
+	keys, err := db.Find(prefix)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, key := range keys {
+		vehicleID := strings.TrimPrefix(key, prefix)
+		Vehicles.telemetry.update(vehicleID, func(t *Telemetry) {
+			err := get(t, key)
+			if err != nil {
+				log.Println(err)
+			}
+		})
+	}
+}
+
+// TelemetryJSON returns the telemetry of the vehicles updated after since,
+// of every vehicle if vehicleIDs is empty
+func (v vehicleList) TelemetryJSON(since time.Time, vehicleIDs []string) map[string]Telemetry {
+	v.telemetry.lock.RLock()
+	defer v.telemetry.lock.RUnlock()
+
+	out := make(map[string]Telemetry)
+	if len(vehicleIDs) == 0 {
+		for vehicleID, t := range v.telemetry.data {
+			if t.Timestamp.After(since) {
+				out[vehicleID] = t
+			}
+		}
+
+		return out
+	}
+
+	for _, vehicleID := range vehicleIDs {
+		if t, ok := v.telemetry.data[vehicleID]; ok && t.Timestamp.After(since) {
+			out[vehicleID] = t
+		}
+	}
+
+	return out
+}
+
+// TelemetryChanged returns a channel closed on the
+// next telemetry update of any vehicle
+func (v vehicleList) TelemetryChanged() <-chan bool {
+	v.telemetry.lock.RLock()
+	defer v.telemetry.lock.RUnlock()
+
+	return v.telemetry.changed
+}
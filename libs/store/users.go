@@ -28,20 +28,59 @@ func newUsers() *users {
 }
 
 func (u *users) Get(token string) *models.User {
-	user := &models.User{}
-	err := db.Get(u.userKey(token), user)
+	var record models.UserRecord
+	err := db.Get(u.userKey(token), &record)
 	if err != nil {
 		log.Println(err)
 		return nil
 	}
 
-	return user
+	return u.load(record)
 
 	//u.RLock()
 	//defer u.RUnlock()
 	//return u.byToken[token]
 }
 
+// GetByID returns a user by ID
+func (u *users) GetByID(id string) *models.User {
+	keys, err := db.Find(userPrefix)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	for _, key := range keys {
+		var record models.UserRecord
+		err := db.Get(key, &record)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if record.ID == id {
+			return u.load(record)
+		}
+	}
+
+	return nil
+}
+
+// load creates a user from its record with its saved permissions
+func (u *users) load(record models.UserRecord) *models.User {
+	user := record.User()
+
+	var permissions models.Permissions
+	err := db.Get(u.permissionsKey(user.ID()), &permissions)
+	if err == nil {
+		user.SetPermissions(permissions)
+	} else if !db.IsNotFoudError(err) {
+		log.Println(err)
+	}
+
+	return user
+}
+
 // GenerateToken renerates an auth token
 // with the given userID
 func (u *users) GenerateToken(vehicleID string) string {
@@ -71,7 +110,13 @@ func (u *users) Authenticate(token string) (*models.User, error) {
 
 // Save saves the user to db
 func (u *users) Save(user *models.User) {
-	db.SetWithTTL(u.userKey(user.Token()), user, time.Minute*10)
+	db.SetWithTTL(u.userKey(user.Token()), user.Record(), time.Minute*10)
+
+	// Permissions are kept when the token expires
+	err := db.Set(u.permissionsKey(user.ID()), user.Permissions())
+	if err != nil {
+		log.Println("Could not save user permissions", err)
+	}
 
 	//u.Lock()
 
@@ -91,6 +136,7 @@ func (u *users) Save(user *models.User) {
 
 func (u *users) Delete(user *models.User) {
 	db.Delete(u.userKey(user.Token()))
+	db.Delete(u.permissionsKey(user.ID()))
 
 	//u.Lock()
 	//if u.byToken[user.Token()] == user {
@@ -105,8 +151,16 @@ func (u *users) Delete(user *models.User) {
 	u.Publish(nil)
 }
 
+var userPrefix = "pilot:"
+
+var permissionsPrefix = "permissions:"
+
 func (u *users) userKey(token string) string {
-	return fmt.Sprintf("pilot:%v", token)
+	return fmt.Sprintf("%s%v", userPrefix, token)
+}
+
+func (u *users) permissionsKey(userID string) string {
+	return fmt.Sprintf("%s%v", permissionsPrefix, userID)
 }
 
 // JSON returns the list of users authorized for each vehicle in a json serializable format
@@ -1,8 +1,10 @@
 package store
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,29 +16,125 @@ import (
 
 type vehicleList struct {
 	*pubsub.Topic
-	vehicles *sync.Map
+	online    *sync.Map // IDs of the vehicles seen online
+	pilots    *sync.Map // pilot of each vehicle by ID
+	telemetry *telemetryCache
 }
 
 func newVehicleList() vehicleList {
 	return vehicleList{
-		Topic:    pubsub.NewTopic(),
-		vehicles: &sync.Map{},
+		Topic:     pubsub.NewTopic(),
+		online:    &sync.Map{},
+		pilots:    &sync.Map{},
+		telemetry: newTelemetryCache(),
 	}
 }
 
-// Connected flags a vehicle as connected
+// Connected flags a vehicle as connected, should be
+// called periodically as a heartbeat
 func (v vehicleList) Connected(vehicleID string) {
-	db.SetWithTTL(v.connectionKey(vehicleID), true, time.Second*5)
-	//v.vehicles.Store(key(vehicle.ID), vehicle)
-	v.Publish(nil)
+	err := db.SetWithTTL(v.connectionKey(vehicleID), true, connectionTimeout)
+	if err != nil {
+		log.Println(err)
+	}
+
+	err = db.Set(v.lastSeenKey(vehicleID), time.Now())
+	if err != nil {
+		log.Println(err)
+	}
+
+	if _, wasOnline := v.online.LoadOrStore(vehicleID, true); !wasOnline {
+		v.Publish(nil)
+	}
 }
 
 // Disconnected flags a vehicle as disconnected
 func (v vehicleList) Disconnected(vehicleID string) {
 	log.Printf("Vehicle '%v' disconnected\n", vehicleID)
 	db.Delete(v.connectionKey(vehicleID))
-	//v.vehicles.Delete(key(vehicle.ID))
+	v.online.Delete(vehicleID)
+	v.Publish(nil)
+}
+
+// Watch flags as disconnected the vehicles whose
+// heartbeat timed out, never returns
+func (v vehicleList) Watch() {
+	for range time.Tick(time.Second) {
+		v.online.Range(func(key interface{}, val interface{}) bool {
+			vehicleID := key.(string)
+			if !v.isOnline(vehicleID) {
+				log.Printf("Vehicle '%v' timed out\n", vehicleID)
+				v.online.Delete(vehicleID)
+				v.Publish(nil)
+			}
+			return true
+		})
+	}
+}
+
+// SetInfo saves the description of a vehicle
+func (v vehicleList) SetInfo(vehicle *models.Vehicle) error {
+	return db.Set(v.infoKey(vehicle.ID), vehicle.Info())
+}
+
+// SetCaps saves the capabilities declared by a vehicle
+func (v vehicleList) SetCaps(vehicleID string, caps models.Caps) error {
+	vehicle := v.Get(vehicleID)
+	if vehicle == nil {
+		vehicle = &models.Vehicle{ID: vehicleID}
+	}
+	vehicle.Caps = caps
+
+	err := v.SetInfo(vehicle)
+	if err != nil {
+		return err
+	}
 	v.Publish(nil)
+
+	return nil
+}
+
+// SetPilot sets the user currently flying a vehicle
+func (v vehicleList) SetPilot(vehicleID string, userID string) {
+	prev, _ := v.pilots.Load(vehicleID)
+	if prev == nil && userID == "" || prev == userID {
+		return
+	}
+
+	if userID == "" {
+		v.pilots.Delete(vehicleID)
+	} else {
+		v.pilots.Store(vehicleID, userID)
+	}
+	v.Publish(nil)
+}
+
+// Info returns the description and the connection state of a vehicle
+func (v vehicleList) Info(vehicleID string) *models.VehicleInfo {
+	info := &models.VehicleInfo{}
+	err := db.Get(v.infoKey(vehicleID), info)
+	if err != nil {
+		return nil
+	}
+
+	info.Online = v.isOnline(vehicleID)
+
+	var lastSeen time.Time
+	if db.Get(v.lastSeenKey(vehicleID), &lastSeen) == nil {
+		info.LastSeen = lastSeen
+	}
+
+	if pilot, ok := v.pilots.Load(vehicleID); ok {
+		info.Pilot = pilot.(string)
+	}
+
+	return info
+}
+
+func (v vehicleList) isOnline(vehicleID string) bool {
+	var connected bool
+	err := db.Get(v.connectionKey(vehicleID), &connected)
+	return err == nil && connected
 }
 
 func (v vehicleList) SetStatus(vehicleID string, status *models.Status) {
@@ -44,6 +142,10 @@ func (v vehicleList) SetStatus(vehicleID string, status *models.Status) {
 	if err != nil {
 		log.Println(err)
 	}
+
+	v.telemetry.update(vehicleID, func(t *Telemetry) { t.Status = *status })
+
+	History.Add(vehicleID, models.TelemetrySample{Timestamp: time.Now(), Status: status})
 }
 
 func (v vehicleList) SetPosition(vehicleID string, pos *models.Position) {
@@ -51,6 +153,10 @@ func (v vehicleList) SetPosition(vehicleID string, pos *models.Position) {
 	if err != nil {
 		log.Println(err)
 	}
+
+	v.telemetry.update(vehicleID, func(t *Telemetry) { t.Position = *pos })
+
+	History.Add(vehicleID, models.TelemetrySample{Timestamp: time.Now(), Position: pos})
 }
 
 func (v vehicleList) SetBattery(vehicleID string, batt *models.Battery) {
@@ -58,172 +164,244 @@ func (v vehicleList) SetBattery(vehicleID string, batt *models.Battery) {
 	if err != nil {
 		log.Println(err)
 	}
+
+	v.telemetry.update(vehicleID, func(t *Telemetry) { t.Battery = *batt })
+
+	History.Add(vehicleID, models.TelemetrySample{Timestamp: time.Now(), Battery: batt})
 }
 
-// Get returns a vehicle by ID
-func (v vehicleList) Get(id string) *models.Vehicle {
-	var vehicle = &models.Vehicle{}
-	err := db.Get(fmt.Sprintf("vehicle:%s", id), vehicle)
+// Position returns the last position received from a vehicle
+func (v vehicleList) Position(vehicleID string) *models.Position {
+	pos := &models.Position{}
+	err := db.Get(v.positionKey(vehicleID), pos)
 	if err != nil {
 		return nil
 	}
 
-	return vehicle
+	return pos
+}
 
-	//val, ok := v.vehicles.Load(key(id))
-	//if !ok {
-	//	return nil
-	//}
-	//vehicle, ok := val.(*models.Vehicle)
-	//if !ok {
-	//	return nil
-	//}
+// Battery returns the last battery state received from a vehicle
+func (v vehicleList) Battery(vehicleID string) *models.Battery {
+	batt := &models.Battery{}
+	err := db.Get(v.batteryKey(vehicleID), batt)
+	if err != nil {
+		return nil
+	}
 
-	//return vehicle
+	return batt
 }
 
-func (v vehicleList) Length() int {
-	return 0
+// Provision registers a vehicle and returns the secret it must use to
+// connect, an already provisioned vehicle gets a new secret only if rotate is true
+func (v vehicleList) Provision(vehicleID string, rotate bool) (string, error) {
+	if vehicleID == "" {
+		return "", errors.New("need vehicleID")
+	}
+
+	if !rotate && v.credentials(vehicleID) != nil {
+		return "", fmt.Errorf("vehicle '%s' is already provisioned", vehicleID)
+	}
 
-	//len := 0
-	//v.vehicles.Range(func(key interface{}, val interface{}) bool {
-	//	len++
-	//	return true
-	//})
+	secret, err := libs.SecureToken(24)
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Set(v.credentialsKey(vehicleID), models.NewVehicleCredentials(vehicleID, secret))
+	if err != nil {
+		return "", err
+	}
+
+	// List the vehicle before its first connection
+	if v.Info(vehicleID) == nil {
+		err = v.SetInfo(&models.Vehicle{ID: vehicleID})
+		if err != nil {
+			return "", err
+		}
+		v.Publish(nil)
+	}
 
-	//return len
+	return secret, nil
 }
 
-// JSON returns the list of vehicles in a json serializable format
-func (v vehicleList) JSON() libs.JSONObject {
-	return libs.JSONObject{}
+// Revoke removes the credentials of a vehicle
+func (v vehicleList) Revoke(vehicleID string) error {
+	if v.credentials(vehicleID) == nil {
+		return fmt.Errorf("vehicle '%s' is not provisioned", vehicleID)
+	}
 
-	//list := libs.JSONObject{}
+	err := db.Delete(v.credentialsKey(vehicleID))
+	if err != nil {
+		return err
+	}
 
-	//v.vehicles.Range(func(key interface{}, val interface{}) bool {
-	//	v := val.(*models.Vehicle)
-	//	list[key.(string)] = v
-	//	return true
-	//})
+	err = db.Delete(v.infoKey(vehicleID))
+	if err != nil {
+		return err
+	}
+	v.Publish(nil)
 
-	//return list
+	return nil
 }
 
-type Telemetry struct {
-	Status models.Status `json:"status"`
-	Position models.Position `json:"position"`
-	Battery models.Battery `json:"battery"`
-	Timestamp time.Time `json:"timestamp"`
+// Authenticate checks the secret of a provisioned vehicle
+func (v vehicleList) Authenticate(vehicleID, secret string) (*models.Vehicle, error) {
+	creds := v.credentials(vehicleID)
+	if creds == nil || !creds.Check(secret) {
+		return nil, errors.New("Invalid vehicle id or token")
+	}
+
+	vehicle := v.Get(vehicleID)
+	if vehicle == nil {
+		vehicle = &models.Vehicle{ID: vehicleID}
+	}
+
+	return vehicle, nil
 }
 
-func NewTelemetry() Telemetry {
-	return Telemetry{Timestamp: time.Now()}
+// Known checks if a vehicle was provisioned or ever connected
+func (v vehicleList) Known(vehicleID string) bool {
+	return v.Info(vehicleID) != nil || v.credentials(vehicleID) != nil
 }
 
-func (v vehicleList) TelemetryJSON(t time.Time) (map[string]Telemetry) {
-	out := make(map[string]Telemetry)
+// Provisioned checks if a vehicle has credentials
+func (v vehicleList) Provisioned(vehicleID string) bool {
+	return v.credentials(vehicleID) != nil
+}
 
-	getStatuses(t, out)
-	getPositions(t, out)
-	getBatteries(t, out)
+func (v vehicleList) credentials(vehicleID string) *models.VehicleCredentials {
+	creds := &models.VehicleCredentials{}
+	err := db.Get(v.credentialsKey(vehicleID), creds)
+	if err != nil {
+		return nil
+	}
 
-	return out
+	return creds
 }
 
-func getStatuses(t time.Time, out map[string]Telemetry) {
-	keys, err := db.Find(statusPrefix)
-	if err != nil {
+// Settings returns the settings of a vehicle or
+// the default settings if it was not configured
+func (v vehicleList) Settings(vehicleID string) models.VehicleSettings {
+	settings := models.DefaultVehicleSettings()
+	err := db.Get(v.settingsKey(vehicleID), &settings)
+	if err != nil && !db.IsNotFoudError(err) {
 		log.Println(err)
-		return
 	}
 
-	for _, key := range keys {
-		var status models.Status
-		err := db.Get(key, &status)
-		if err != nil {
-			log.Println(err)
-		} else {
-			id := key[len(statusPrefix):]
+	return settings
+}
 
-			val, ok := out[id];
-			if !ok {
-				val = NewTelemetry()
-			}
+// SetSettings saves the settings of a vehicle
+func (v vehicleList) SetSettings(vehicleID string, settings models.VehicleSettings) error {
+	return db.Set(v.settingsKey(vehicleID), settings)
+}
+
+// Get returns a vehicle by ID
+func (v vehicleList) Get(id string) *models.Vehicle {
+	info := v.Info(id)
+	if info == nil {
+		return nil
+	}
+
+	return &models.Vehicle{
+		ID:    info.ID,
+		Name:  info.Name,
+		Model: info.Model,
+		Caps:  info.Caps,
+	}
+}
+
+// Length returns the number of known vehicles
+func (v vehicleList) Length() int {
+	return len(v.GetIDs())
+}
 
-			val.Status = status
-			out[id] = val
+// JSON returns the list of vehicles in a json serializable format
+func (v vehicleList) JSON() libs.JSONObject {
+	list := libs.JSONObject{}
+	for _, id := range v.GetIDs() {
+		if info := v.Info(id); info != nil {
+			list[id] = info
 		}
 	}
+
+	return list
 }
 
-func getPositions(t time.Time, out map[string]Telemetry) {
-	keys, err := db.Find(positionPrefix)
+//GetIDs returns ths IDs of all vehicles in this list
+func (v vehicleList) GetIDs() []string {
+	keys, err := db.Find(infoPrefix)
 	if err != nil {
 		log.Println(err)
-		return
+		return []string{}
 	}
 
+	ids := []string{}
 	for _, key := range keys {
-		var pos models.Position
-		err := db.Get(key, &pos)
-		if err != nil {
-			log.Println(err)
-		} else {
-			id := key[len(positionPrefix):]
-
-			val, ok := out[id];
-			if !ok {
-				val = NewTelemetry()
-			}
-
-			val.Position = pos
-			out[id] = val
-		}
+		ids = append(ids, key[len(infoPrefix):])
 	}
+
+	return ids
 }
 
-func getBatteries(t time.Time, out map[string]Telemetry) {
-	keys, err := db.Find(batteryPrefix)
+// MigrateInfo moves the vehicles saved under their
+// legacy "vehicle:<id>" key to their info key
+func (v vehicleList) MigrateInfo() {
+	keys, err := db.Find(legacyVehiclePrefix)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 
 	for _, key := range keys {
-		var batt models.Battery
-		err := db.Get(key, &batt)
+		vehicleID := key[len(legacyVehiclePrefix):]
+		if strings.Contains(vehicleID, ":") {
+			// not a legacy key but one of the current "vehicle:<kind>:<id>" keys
+			continue
+		}
+
+		vehicle := &models.Vehicle{}
+		err := db.Get(key, vehicle)
 		if err != nil {
-			log.Println(err)
-		} else {
-			id := key[len(batteryPrefix):]
+			log.Printf("Cannot get legacy vehicle '%v': %v", vehicleID, err)
+			continue
+		}
+		vehicle.ID = vehicleID
 
-			val, ok := out[id];
-			if !ok {
-				val = NewTelemetry()
+		if v.Info(vehicleID) == nil {
+			err = v.SetInfo(vehicle)
+			if err != nil {
+				log.Printf("Could not migrate vehicle '%v': %v", vehicleID, err)
+				continue
 			}
+		}
 
-			val.Battery = batt
-			out[id] = val
+		err = db.Delete(key)
+		if err != nil {
+			log.Println(err)
 		}
 	}
 }
 
-//GetIDs returns ths IDs of all vehicles in this list
-func (v vehicleList) GetIDs() []string {
-	return []string{}
+// connectionTimeout is the time after which a vehicle
+// is offline if it did not send a heartbeat
+var connectionTimeout = time.Second * 5
 
-	//ids := []string{}
+func (v vehicleList) connectionKey(vehicleID string) string {
+	return fmt.Sprintf("vehicle:connected:%s", vehicleID)
+}
+
+var legacyVehiclePrefix = "vehicle:"
 
-	//v.vehicles.Range(func(key interface{}, val interface{}) bool {
-	//	ids = append(ids, key.(string))
-	//	return true
-	//})
+var infoPrefix = "vehicle:info:"
 
-	//return ids
+func (v vehicleList) infoKey(vehicleID string) string {
+	return fmt.Sprintf("%s%s", infoPrefix, vehicleID)
 }
 
-func (v vehicleList) connectionKey(vehicleID string) string {
-	return fmt.Sprintf("vehicle:connected:%s", vehicleID)
+func (v vehicleList) lastSeenKey(vehicleID string) string {
+	return fmt.Sprintf("vehicle:lastseen:%s", vehicleID)
 }
 
 var statusPrefix = "vehicle:status:"
@@ -240,3 +418,11 @@ var batteryPrefix = "vehicle:battery:"
 func (v vehicleList) batteryKey(vehicleID string) string {
 	return fmt.Sprintf("%s%s", batteryPrefix, vehicleID)
 }
+
+func (v vehicleList) settingsKey(vehicleID string) string {
+	return fmt.Sprintf("vehicle:settings:%s", vehicleID)
+}
+
+func (v vehicleList) credentialsKey(vehicleID string) string {
+	return fmt.Sprintf("vehicle:credentials:%s", vehicleID)
+}
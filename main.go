@@ -2,12 +2,15 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/volons/hive/controllers"
 	"github.com/volons/hive/libs"
+	"github.com/volons/hive/libs/autopilot"
 	"github.com/volons/hive/libs/db"
+	"github.com/volons/hive/libs/store"
 	"github.com/volons/hive/libs/websocket"
 	"github.com/volons/hive/messages"
 	"github.com/volons/hive/models"
@@ -26,6 +29,9 @@ func main() {
 	// Init configuration
 	//
 	configFilePath := flag.String("config", "", "path to the config file")
+	createAdmin := flag.String("create-admin", "", "create an admin account with the given name, print its api key and exit")
+	adminRole := flag.String("admin-role", string(models.RoleSupervisor), "role of the admin created with -create-admin: viewer, operator or supervisor")
+	revokeAdmin := flag.String("revoke-admin", "", "revoke the admin account with the given ID and exit")
 	flag.Parse()
 	config.Read(*configFilePath)
 	conf := config.Get()
@@ -39,16 +45,68 @@ func main() {
 	}
 
 	//
-	// Init fence
+	// Manage admin accounts
 	//
-	fence := models.FenceData{}
-	err = db.Get("fence", &fence)
+	if *createAdmin != "" {
+		admin, key, err := store.Admins.Create(*createAdmin, models.Role(*adminRole), nil)
+		if err != nil {
+			log.Fatalf("Could not create admin: %v", err)
+		}
+		fmt.Printf("Created %v '%v'\nid: %v\nkey: %v\n", admin.Role(), admin.Name(), admin.ID(), key)
+		return
+	}
+	if *revokeAdmin != "" {
+		err := store.Admins.Revoke(*revokeAdmin)
+		if err != nil {
+			log.Fatalf("Could not revoke admin: %v", err)
+		}
+		fmt.Printf("Revoked admin '%v'\n", *revokeAdmin)
+		return
+	}
+	if store.Admins.Length() == 0 {
+		log.Println("No admin account, create one with -create-admin <name>")
+	}
+	if conf.AllowUnprovisioned {
+		log.Println("Vehicles that were not provisioned can connect without a secret, provision them with vehicle:provision")
+	}
+
+	//
+	// Save the flights interrupted by a restart
+	//
+	store.Flights.CloseOpen()
+
+	//
+	// Migrate vehicles saved by previous versions
+	//
+	store.Vehicles.MigrateInfo()
+
+	//
+	// Init fences
+	//
+	store.Fences.Load()
+
+	//
+	// Init last known telemetry and watch vehicle connections
+	//
+	store.Vehicles.LoadTelemetry()
+	go store.Vehicles.Watch()
+
+	//
+	// Init the separation monitor between vehicles
+	//
+	go autopilot.MonitorSeparation()
+
+	//
+	// Init no fly zones
+	//
+	noFly := models.NoFlyData{}
+	err = db.Get("nofly", &noFly)
 	if err != nil {
-		log.Printf("Cannot get fence: %v", err)
+		log.Printf("Cannot get no fly zones: %v", err)
 	} else {
-		err = models.SetFence(fence)
+		err = models.SetNoFlyZones(noFly)
 		if err != nil {
-			log.Printf("Cound not set fence: %v", err)
+			log.Printf("Cound not set no fly zones: %v", err)
 		}
 	}
 
@@ -107,7 +165,17 @@ func main() {
 		case "rc":
 			return &models.Rc{}
 		case "fence:set":
-			return &models.FenceData{}
+			return &models.VehicleFence{}
+		case "fence:params":
+			return &models.VehicleFenceParams{}
+		case "nofly:set":
+			return &models.NoFlyData{}
+		case "mission:start":
+			return &models.VehicleMission{}
+		case "mission:save", "mission:validate":
+			return &models.MissionPlan{}
+		case "mission:pattern":
+			return &models.VehiclePattern{}
 		case "webrtc:sdp":
 			return &models.SessionDescription{}
 		case "webrtc:icecandidate":
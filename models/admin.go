@@ -1,39 +1,194 @@
 package models
 
-import "github.com/volons/hive/libs"
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
 
-// User model
+	"github.com/volons/hive/libs"
+)
+
+// Admin model
 type Admin struct {
-	id    string
-	token string
-	done  libs.Done
+	id       string
+	name     string
+	role     Role
+	vehicles []string
+	keyHash  string
+	created  time.Time
+	done     libs.Done
+	lock     *sync.RWMutex
+}
+
+// AdminInfo is the public description of an admin account
+type AdminInfo struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Role     Role      `json:"role"`
+	Vehicles []string  `json:"vehicles"`
+	Created  time.Time `json:"created"`
 }
 
-// NewUser creates a new user
-func NewAdmin(id, token string) *Admin {
+// adminJSON is the json serializable version of the admin struct
+type adminJSON struct {
+	AdminInfo
+	KeyHash string `json:"keyHash"`
+}
+
+// NewAdmin creates a new admin, an empty list of
+// vehicles gives access to every vehicle
+func NewAdmin(id, name string, role Role, vehicles []string) *Admin {
 	return &Admin{
-		id:    id,
-		token: token,
-		done:  libs.NewDone(),
+		id:       id,
+		name:     name,
+		role:     role,
+		vehicles: vehicles,
+		created:  time.Now(),
+		done:     libs.NewDone(),
+		lock:     &sync.RWMutex{},
+	}
+}
+
+// MarshalJSON encodes the admin into json
+func (u *Admin) MarshalJSON() ([]byte, error) {
+	return json.Marshal(adminJSON{
+		AdminInfo: u.Info(),
+		KeyHash:   u.keyHash,
+	})
+}
+
+// UnmarshalJSON decodes an admin from json
+func (u *Admin) UnmarshalJSON(data []byte) error {
+	var val adminJSON
+	err := json.Unmarshal(data, &val)
+	if err != nil {
+		return err
+	}
+
+	// Accounts created before roles existed keep full access
+	if val.Role == "" {
+		val.Role = RoleSupervisor
 	}
+
+	*u = *NewAdmin(val.ID, val.Name, val.Role, val.Vehicles)
+	u.created = val.Created
+	u.keyHash = val.KeyHash
+
+	return nil
 }
 
-// ID returns the user's ID
+// ID returns the admin's ID
 func (u *Admin) ID() string {
 	return u.id
 }
 
-// Token returns the user's token
-func (u *Admin) Token() string {
-	return u.token
+// Name returns the admin's name
+func (u *Admin) Name() string {
+	return u.name
+}
+
+// Role returns the admin's role
+func (u *Admin) Role() Role {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	return u.role
+}
+
+// Vehicles returns the IDs of the vehicles the admin has access to,
+// an empty list means every vehicle
+func (u *Admin) Vehicles() []string {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	return u.vehicles
+}
+
+// SetAccess changes the admin's role and the vehicles it has access to
+func (u *Admin) SetAccess(role Role, vehicles []string) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.role = role
+	u.vehicles = vehicles
+}
+
+// Can checks if the admin's role grants at least the rights of role
+func (u *Admin) Can(role Role) bool {
+	return u.Role().Includes(role)
+}
+
+// CanAccessVehicle checks if the admin has access to a vehicle
+func (u *Admin) CanAccessVehicle(vehicleID string) bool {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if len(u.vehicles) == 0 {
+		return true
+	}
+
+	for _, id := range u.vehicles {
+		if id == vehicleID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Info returns the public description of the admin
+func (u *Admin) Info() AdminInfo {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	vehicles := u.vehicles
+	if vehicles == nil {
+		vehicles = []string{}
+	}
+
+	return AdminInfo{
+		ID:       u.id,
+		Name:     u.name,
+		Role:     u.role,
+		Vehicles: vehicles,
+		Created:  u.created,
+	}
+}
+
+// SetKey sets the api key used by the admin to authenticate,
+// only a hash of the key is kept
+func (u *Admin) SetKey(key string) {
+	u.keyHash = hashKey(key)
+}
+
+// CheckKey checks if the provided api key is the admin's key
+func (u *Admin) CheckKey(key string) bool {
+	return checkKey(u.keyHash, key)
 }
 
-// Done returns the user's done channel
+// Done returns the admin's done channel
 func (u *Admin) Done() <-chan bool {
 	return u.done.WaitCh()
 }
 
-// Close closes the user's done channel
+// Close closes the admin's done channel
 func (u *Admin) Close() {
 	u.done.Done()
 }
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkKey checks in constant time if key matches the hash
+func checkKey(hash string, key string) bool {
+	if hash == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hashKey(key))) == 1
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Sources of the audited messages
+const (
+	AuditSourceAdmin     = "admin"
+	AuditSourceUser      = "user"
+	AuditSourceAutopilot = "autopilot"
+)
+
+// AuditEntry records a message sent to a vehicle
+// or an admin command, along with its outcome
+type AuditEntry struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Source    string      `json:"source"`
+	SourceID  string      `json:"sourceID,omitempty"`
+	VehicleID string      `json:"vehicleID,omitempty"`
+	Type      string      `json:"type"`
+	Payload   interface{} `json:"payload,omitempty"`
+	Reply     interface{} `json:"reply,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// NewAuditEntry creates a new audit entry
+func NewAuditEntry(source, sourceID, vehicleID, typ string, payload interface{}) AuditEntry {
+	return AuditEntry{
+		Timestamp: time.Now(),
+		Source:    source,
+		SourceID:  sourceID,
+		VehicleID: vehicleID,
+		Type:      typ,
+		Payload:   payload,
+	}
+}
+
+// NewUserAuditEntry creates an audit entry for a message sent by a user
+// connected to a vehicle, admins connect through channels as "admin:<id>"
+func NewUserAuditEntry(userID, vehicleID, typ string, payload interface{}) AuditEntry {
+	if strings.HasPrefix(userID, "admin:") {
+		return NewAuditEntry(AuditSourceAdmin, userID[len("admin:"):], vehicleID, typ, payload)
+	}
+
+	return NewAuditEntry(AuditSourceUser, userID, vehicleID, typ, payload)
+}
+
+// SetResult sets the reply or error of the audited message
+func (e *AuditEntry) SetResult(reply interface{}, err error) {
+	e.Reply = reply
+	if err != nil {
+		e.Error = err.Error()
+	}
+}
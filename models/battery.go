@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 // Battery contains the vehicles battery data
 type Battery struct {
 	Voltage float64 `json:"voltage"`
@@ -15,3 +17,29 @@ func NewBattery(voltage, current, percent float64) Battery {
 		percent,
 	}
 }
+
+// BatteryLevel is the state of the battery failsafe of a vehicle
+type BatteryLevel int
+
+// Battery levels from the safest to the most critical
+const (
+	BatteryOK BatteryLevel = iota
+	BatteryWarning
+	BatteryCritical
+	BatteryEmergency
+)
+
+var batteryLevelNames = []string{"ok", "warning", "critical", "emergency"}
+
+func (l BatteryLevel) String() string {
+	if l < 0 || int(l) >= len(batteryLevelNames) {
+		return "unknown"
+	}
+
+	return batteryLevelNames[l]
+}
+
+// MarshalJSON encodes the level as its name
+func (l BatteryLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"os"
+	"time"
 )
 
 // Config represents the configuration data
@@ -10,6 +11,14 @@ type Config struct {
 	VolonsPlatform string `json:"volons_platform"`
 	HTTPAddr       string `json:"http"`
 	Database       string `json:"database"`
+
+	// HistoryRetention is how long telemetry history is kept,
+	// as a duration string such as "168h"
+	HistoryRetention string `json:"history_retention"`
+
+	// AllowUnprovisioned lets vehicles that were not provisioned connect
+	// without a secret like before provisioning, until they all are
+	AllowUnprovisioned bool `json:"allow_unprovisioned_vehicles"`
 }
 
 // Init conf with defaults
@@ -17,8 +26,12 @@ var _conf = Config{
 	VolonsPlatform: "", //"https://api.volons.fr/gcs",
 	HTTPAddr:       "0.0.0.0:8656",
 	Database:       "./database/",
+
+	HistoryRetention: "168h",
 }
 
+var defaultHistoryRetention = time.Hour * 24 * 7
+
 // Get returns the global config
 func Get() Config {
 	return _conf
@@ -30,6 +43,8 @@ func Read(filePath string) {
 		_conf.VolonsPlatform = getEnv("VOLONS_PLATFORM", _conf.VolonsPlatform)
 		_conf.HTTPAddr = getEnv("VOLONS_HTTP", _conf.HTTPAddr)
 		_conf.Database = getEnv("VOLONS_DATABASE", _conf.Database)
+		_conf.HistoryRetention = getEnv("VOLONS_HISTORY_RETENTION", _conf.HistoryRetention)
+		_conf.AllowUnprovisioned = getEnv("VOLONS_ALLOW_UNPROVISIONED_VEHICLES", "") == "true"
 		return
 	}
 
@@ -44,6 +59,16 @@ func Read(filePath string) {
 	}
 }
 
+// Retention returns how long telemetry history is kept
+func (c Config) Retention() time.Duration {
+	d, err := time.ParseDuration(c.HistoryRetention)
+	if err != nil || d <= 0 {
+		return defaultHistoryRetention
+	}
+
+	return d
+}
+
 func getEnv(name string, defaultVal string) string {
 	val := os.Getenv(name)
 
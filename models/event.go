@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Severity of the events
+const (
+	EventInfo     = "info"
+	EventWarning  = "warning"
+	EventCritical = "critical"
+)
+
+// Event is something that happened to a vehicle that admins should know about
+type Event struct {
+	Type      string      `json:"type"`
+	VehicleID string      `json:"vehicleID"`
+	Severity  string      `json:"severity"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// NewEvent creates a new event
+func NewEvent(typ, vehicleID, severity, message string, data interface{}) Event {
+	return Event{
+		Type:      typ,
+		VehicleID: vehicleID,
+		Severity:  severity,
+		Message:   message,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+}
@@ -0,0 +1,12 @@
+package models
+
+// FailsafeState is the state of the link loss failsafe of a vehicle
+type FailsafeState string
+
+// Failsafe states
+const (
+	FailsafeNone FailsafeState = "none"
+	FailsafeHold FailsafeState = "hold"
+	FailsafeRTL  FailsafeState = "rtl"
+	FailsafeLand FailsafeState = "land"
+)
@@ -3,16 +3,19 @@ package models
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"math"
 	"os"
+	"sync"
 	"time"
 )
 
 // The Fence class allows to restrain a vehicles movements
+// inside one or more polygonal zones
 type Fence struct {
-	a Position
-	b Position
+	zones  []Zone
+	params FenceParams
 }
 
 // PointData represents a point in the world
@@ -23,48 +26,125 @@ type PointData struct {
 }
 
 // FenceData is a json serializable version of fence struct
-type FenceData []PointData
+type FenceData struct {
+	Zones  []ZoneData   `json:"zones"`
+	Params *FenceParams `json:"params,omitempty"`
+}
 
-var fenceInstance *Fence
+// UnmarshalJSON parses a fence and supports the legacy
+// format made of exactly two points describing a box
+func (data *FenceData) UnmarshalJSON(b []byte) error {
+	var points []PointData
+	if err := json.Unmarshal(b, &points); err == nil {
+		if len(points) != 2 {
+			return errors.New("Fence should have exactly two points")
+		}
 
-// GetFence returns the current fence
-func GetFence() *Fence {
-	return fenceInstance
+		data.Zones = []ZoneData{newBoxZone(points[0], points[1])}
+		return nil
+	}
+
+	type fenceData FenceData
+	return json.Unmarshal(b, (*fenceData)(data))
 }
 
-// SetFence sets the current fence
-func SetFence(data FenceData) error {
-	if len(data) != 2 {
-		return errors.New("Fence should have exactly two points")
+// VehicleFence associates a fence to a vehicle
+type VehicleFence struct {
+	VehicleID string    `json:"vehicleID"`
+	Fence     FenceData `json:"fence"`
+}
+
+var fences = struct {
+	sync.RWMutex
+	byVehicleID map[string]*Fence
+}{
+	byVehicleID: make(map[string]*Fence),
+}
+
+// GetFence returns the fence of a vehicle
+func GetFence(vehicleID string) *Fence {
+	fences.RLock()
+	defer fences.RUnlock()
+	return fences.byVehicleID[vehicleID]
+}
+
+// GetFences returns the fences of every vehicle by vehicle ID
+func GetFences() map[string]*Fence {
+	fences.RLock()
+	defer fences.RUnlock()
+
+	out := make(map[string]*Fence)
+	for vehicleID, fence := range fences.byVehicleID {
+		out[vehicleID] = fence
 	}
 
-	fence := &Fence{
-		a: NewPoint(data[0].Lat, data[0].Lon, data[0].Alt),
-		b: NewPoint(data[1].Lat, data[1].Lon, data[1].Alt),
+	return out
+}
+
+// NewFence creates a fence from its json representation
+func NewFence(data FenceData) (*Fence, error) {
+	if len(data.Zones) == 0 {
+		return nil, errors.New("Fence should have at least one zone")
 	}
 
-	if !fence.Valid() {
-		return errors.New("fence is not valid")
+	fence := &Fence{params: DefaultFenceParams()}
+	if data.Params != nil {
+		if err := data.Params.Validate(); err != nil {
+			return nil, err
+		}
+		fence.params = *data.Params
 	}
 
-	fenceInstance = fence
+	for i, zoneData := range data.Zones {
+		zone, err := NewZone(zoneData)
+		if err != nil {
+			return nil, fmt.Errorf("zone %d: %v", i, err)
+		}
+		fence.zones = append(fence.zones, zone)
+	}
+
+	return fence, nil
+}
+
+// SetFence sets the fence of a vehicle
+func SetFence(vehicleID string, data FenceData) error {
+	fence, err := NewFence(data)
+	if err != nil {
+		return err
+	}
+
+	fences.Lock()
+	fences.byVehicleID[vehicleID] = fence
+	fences.Unlock()
 
 	return nil
 }
 
-// SetFenceJSON sets the current fence in json format
-func SetFenceJSON(data []byte) error {
+// ClearFence removes the fence of a vehicle
+// returns true if the vehicle had a fence
+func ClearFence(vehicleID string) bool {
+	fences.Lock()
+	defer fences.Unlock()
+
+	_, ok := fences.byVehicleID[vehicleID]
+	delete(fences.byVehicleID, vehicleID)
+
+	return ok
+}
+
+// SetFenceJSON sets the fence of a vehicle in json format
+func SetFenceJSON(vehicleID string, data []byte) error {
 	var fence FenceData
 	err := json.Unmarshal(data, &fence)
 	if err != nil {
 		return err
 	}
 
-	return SetFence(fence)
+	return SetFence(vehicleID, fence)
 }
 
-// SetFenceFile creates the current fence according to the specified file
-func SetFenceFile(fenceFilePath string) error {
+// SetFenceFile creates the fence of a vehicle according to the specified file
+func SetFenceFile(vehicleID string, fenceFilePath string) error {
 	fenceFile, err := os.Open(fenceFilePath)
 	if err != nil {
 		return err
@@ -76,31 +156,28 @@ func SetFenceFile(fenceFilePath string) error {
 		return err
 	}
 
-	return SetFence(data)
+	return SetFence(vehicleID, data)
 }
 
-// A return the a point
-func (fence *Fence) A() Position {
-	return fence.a
+// Zones returns the inclusion zones of the fence
+func (fence *Fence) Zones() []Zone {
+	return fence.zones
 }
 
-// B return the b point
-func (fence *Fence) B() Position {
-	return fence.b
+// Params returns the tuning parameters of the fence
+func (fence *Fence) Params() FenceParams {
+	return fence.params
 }
 
 // Check checks if the provided position is inside the fence
 func (fence *Fence) Check(pos Position) bool {
-	if pos.Lon > fence.b.Lon ||
-		pos.Lon < fence.a.Lon ||
-		pos.Lat > fence.b.Lat ||
-		pos.Lat < fence.a.Lat ||
-		pos.RelAlt > fence.b.RelAlt ||
-		pos.RelAlt < fence.a.RelAlt {
-		return false
+	for _, zone := range fence.zones {
+		if zone.Contains(pos) {
+			return true
+		}
 	}
 
-	return true
+	return false
 }
 
 // GetAutoRc returns the corrcted rc values to not leave the fence
@@ -112,79 +189,78 @@ func (fence *Fence) GetAutoRc(pos Position, manualRc *Rc) (*Rc, *Position, bool,
 	if !fence.Check(pos) {
 		outside = true
 		target = &Position{}
-		*target = pos
+		*target = fence.recoveryTarget(pos, fence.params.PushBack)
+		autoRc = NewNullRc()
+	} else {
+		autoRc = manualRc.Copy()
 
-		var tX, tY, tZ float64
+		limits := fence.limits(pos, fence.params)
 
-		if target.Lon > fence.b.Lon {
-			target.SetLon(fence.b.Lon)
-			tX = -5
-		} else if target.Lon < fence.a.Lon {
-			target.SetLon(fence.a.Lon)
-			tX = 5
-		}
+		autoRc.Rotate(-pos.Hdg)
+		slowed = autoRc.ApplyLimits(limits)
+		autoRc.Rotate(pos.Hdg)
 
-		if target.Lat > fence.b.Lat {
-			target.SetLat(fence.b.Lat)
-			tY = -5
-		} else if target.Lat < fence.a.Lat {
-			target.SetLat(fence.a.Lat)
-			tY = 5
-		}
+		//debug(pos, limits, autoRc)
+	}
 
-		if target.RelAlt > fence.b.RelAlt {
-			target.SetRelAlt(fence.b.RelAlt)
-			tZ = -5
-		} else if target.RelAlt < fence.a.RelAlt {
-			target.SetRelAlt(fence.a.RelAlt)
-			tZ = 5
-		}
+	return autoRc, target, slowed, outside
+}
 
-		*target = target.Translate(tX, tY, tZ)
-		autoRc = NewNullRc()
-	} else {
-		autoRc = manualRc.Copy()
+// limits returns the rc limits to apply when the vehicle
+// gets close to the edges of the fence or moves fast toward them
+func (fence *Fence) limits(pos Position, params FenceParams) RcLimits {
+	var east, west, north, south, up, down float64
 
-		m := 15.0
-		f := pos.Translate(0, m, 0)
-		b := pos.Translate(0, -m, 0)
-		l := pos.Translate(-m, 0, 0)
-		r := pos.Translate(m, 0, 0)
-		u := pos.Translate(0, 0, m)
-		d := pos.Translate(0, 0, -m)
+	for _, zone := range fence.zones {
+		if !zone.Contains(pos) {
+			continue
+		}
 
-		xa, ya, za := fence.meterDist(pos, fence.a)
-		xb, yb, zb := fence.meterDist(pos, fence.b)
+		east = math.Max(east, zone.RayDistance(pos, 1, 0))
+		west = math.Max(west, zone.RayDistance(pos, -1, 0))
+		north = math.Max(north, zone.RayDistance(pos, 0, 1))
+		south = math.Max(south, zone.RayDistance(pos, 0, -1))
+		up = math.Max(up, zone.Ceiling()-pos.RelAlt)
+		down = math.Max(down, pos.RelAlt-zone.Floor())
+	}
 
-		limits := NewRcLimits()
+	return params.limits(pos, east, west, north, south, up, down)
+}
 
-		if !fence.Check(r) {
-			limits.RollMax = xb / m
-		}
-		if !fence.Check(l) {
-			limits.RollMin = xa / m
-		}
-		if !fence.Check(f) {
-			limits.PitchMax = yb / m
-		}
-		if !fence.Check(b) {
-			limits.PitchMin = ya / m
+// recoveryTarget returns a position inside the fence, close to
+// the provided position, at which the vehicle should be sent
+func (fence *Fence) recoveryTarget(pos Position, pushBack float64) Position {
+	var nearest Zone
+	var tX, tY, tZ float64
+
+	dist := math.Inf(1)
+	for _, zone := range fence.zones {
+		if zone.ContainsPoint(pos) {
+			nearest, tX, tY, dist = zone, 0, 0, 0
+			break
 		}
-		if !fence.Check(u) {
-			limits.ThrottleMax = zb / m
-		}
-		if !fence.Check(d) {
-			limits.ThrottleMin = za / m
+
+		x, y, d := zone.Closest(pos)
+		if d < dist {
+			nearest, tX, tY, dist = zone, x, y, d
 		}
+	}
 
-		autoRc.Rotate(-pos.Hdg)
-		slowed = autoRc.ApplyLimits(limits)
-		autoRc.Rotate(pos.Hdg)
+	if dist > 0 {
+		tX += tX / dist * pushBack
+		tY += tY / dist * pushBack
+	}
 
-		//debug(pos, limits, autoRc)
+	target := pos
+	if target.RelAlt > nearest.Ceiling() {
+		target.SetRelAlt(nearest.Ceiling())
+		tZ = -pushBack
+	} else if target.RelAlt < nearest.Floor() {
+		target.SetRelAlt(nearest.Floor())
+		tZ = pushBack
 	}
 
-	return autoRc, target, slowed, outside
+	return target.Translate(tX, tY, tZ)
 }
 
 var last time.Time
@@ -200,38 +276,13 @@ func debug(pos Position, limits RcLimits, rc *Rc) {
 	}
 }
 
-func (fence *Fence) meterDist(p1 Position, p2 Position) (x, y, z float64) {
-	rEarth := float64(6371000)
-	//PI := 3.1415926535
-	rad := math.Pi / 180
-
-	deg := 180 / math.Pi
-	diffLat := p2.Lat - p1.Lat
-	diffLon := p2.Lon - p1.Lon
-	diffAlt := p2.RelAlt - p1.RelAlt
-	latRad := p1.Lat * rad
-
-	return (diffLon * math.Cos(latRad) / deg) * rEarth, (diffLat / deg) * rEarth, diffAlt
-}
-
-// PrintDiff prints the distance between the fences
-// points and the supplied position
-func (fence *Fence) PrintDiff(pos Position) {
-	log.Printf("Fence diff: Lat: %v, Lon: %v\n", pos.Lat-fence.a.Lat, pos.Lon-fence.a.Lon)
-	log.Printf("Fence diff: Lat: %v, Lon: %v\n", pos.Lat-fence.b.Lat, pos.Lon-fence.b.Lon)
-}
-
 // JSON returns a to JSON convertable representation of this fence
 func (fence *Fence) JSON() FenceData {
-	return FenceData{
-		PointData{fence.A().Lat, fence.A().Lon, fence.A().RelAlt},
-		PointData{fence.B().Lat, fence.B().Lon, fence.B().RelAlt},
+	params := fence.params
+	data := FenceData{Zones: []ZoneData{}, Params: &params}
+	for _, zone := range fence.zones {
+		data.Zones = append(data.Zones, zone.JSON())
 	}
-}
 
-// Valid checks if the fence is valid
-func (fence *Fence) Valid() bool {
-	return fence.A().Lat < fence.B().Lat &&
-		fence.A().Lon < fence.B().Lon &&
-		fence.A().RelAlt < fence.B().RelAlt
+	return data
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// FenceEvent records a change of the fence state of a vehicle
+type FenceEvent struct {
+	VehicleID string     `json:"vehicleID"`
+	UserID    string     `json:"userID"`
+	State     FenceState `json:"state"`
+	Position  Position   `json:"position"`
+	Rc        RcData     `json:"rc"`
+	AutoRc    RcData     `json:"autoRc"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// NewFenceEvent creates and returns a new fence event
+func NewFenceEvent(vehicleID, userID string, state FenceState, pos Position, rc, autoRc *Rc) FenceEvent {
+	return FenceEvent{
+		VehicleID: vehicleID,
+		UserID:    userID,
+		State:     state,
+		Position:  pos,
+		Rc:        rc.Data(),
+		AutoRc:    autoRc.Data(),
+		Timestamp: time.Now(),
+	}
+}
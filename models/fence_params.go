@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"math"
+)
+
+// FenceParams holds the tuning parameters of a fence
+type FenceParams struct {
+	Margin      float64 `json:"margin"`      // Distance to an edge in m at which the vehicle starts to slow down
+	SpeedMargin float64 `json:"speedMargin"` // Time in s added to the margin, multiplied by the speed toward an edge
+	PushBack    float64 `json:"pushBack"`    // Distance in m inside the fence to which an outside vehicle is sent back
+	Horizon     float64 `json:"horizon"`     // Time in s over which the position is predicted to brake before an edge
+}
+
+// VehicleFenceParams associates fence parameters to a vehicle
+type VehicleFenceParams struct {
+	VehicleID string      `json:"vehicleID"`
+	Params    FenceParams `json:"params"`
+}
+
+// DefaultFenceParams returns the parameters used when
+// a fence does not define its own
+func DefaultFenceParams() FenceParams {
+	return FenceParams{
+		Margin:      15,
+		SpeedMargin: 0,
+		PushBack:    5,
+		Horizon:     2,
+	}
+}
+
+// Validate checks the parameters values
+func (p FenceParams) Validate() error {
+	if p.Margin <= 0 {
+		return errors.New("margin should be greater than 0")
+	}
+	if p.SpeedMargin < 0 {
+		return errors.New("speedMargin should not be negative")
+	}
+	if p.PushBack < 0 {
+		return errors.New("pushBack should not be negative")
+	}
+	if p.Horizon < 0 {
+		return errors.New("horizon should not be negative")
+	}
+
+	return nil
+}
+
+// margins returns the margins in m toward east, west,
+// north, south, up and down according to the velocity
+func (p FenceParams) margins(pos Position) (east, west, north, south, up, down float64) {
+	// Vx is positive north, Vy positive east and Vz positive down
+	east = p.Margin + p.SpeedMargin*math.Max(0, pos.Vy)
+	west = p.Margin + p.SpeedMargin*math.Max(0, -pos.Vy)
+	north = p.Margin + p.SpeedMargin*math.Max(0, pos.Vx)
+	south = p.Margin + p.SpeedMargin*math.Max(0, -pos.Vx)
+	up = p.Margin + p.SpeedMargin*math.Max(0, -pos.Vz)
+	down = p.Margin + p.SpeedMargin*math.Max(0, pos.Vz)
+
+	return east, west, north, south, up, down
+}
+
+// limits returns the rc limits to apply given the distances in m to
+// the closest edges toward east, west, north, south, up and down
+func (p FenceParams) limits(pos Position, east, west, north, south, up, down float64) RcLimits {
+	mEast, mWest, mNorth, mSouth, mUp, mDown := p.margins(pos)
+
+	limits := NewRcLimits()
+	limits.RollMax = p.limit(east, mEast, pos.Vy)
+	limits.RollMin = -p.limit(west, mWest, -pos.Vy)
+	limits.PitchMax = p.limit(north, mNorth, pos.Vx)
+	limits.PitchMin = -p.limit(south, mSouth, -pos.Vx)
+	limits.ThrottleMax = p.limit(up, mUp, -pos.Vz)
+	limits.ThrottleMin = -p.limit(down, mDown, pos.Vz)
+
+	return limits
+}
+
+// limit returns the maximum rc value toward an edge at distance d
+// with a margin m while moving toward it at speed v
+func (p FenceParams) limit(d, m, v float64) float64 {
+	limit := 1.0
+	if d < m {
+		limit = d / m
+	}
+
+	// Brake according to the time left before reaching
+	// the edge if the predicted position is beyond it
+	if v > 0 && d < v*p.Horizon {
+		limit = math.Min(limit, d/v/p.Horizon)
+	}
+
+	return limit
+}
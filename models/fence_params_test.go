@@ -0,0 +1,71 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+// approachEdge flies toward the north edge of a 200m box fence at the given
+// speed with the pitch stick fully forward, and returns the pitch allowed
+// every 0.1m from 100m to 0.1m of the edge, the first value being the farthest
+func approachEdge(t *testing.T, speed float64) []float64 {
+	params := DefaultFenceParams()
+	params.SpeedMargin = 1
+
+	origin := NewPoint(48.85, 2.35, 0)
+	corner := origin.Translate(200, 200, 0)
+	fence, err := NewFence(FenceData{
+		Zones: []ZoneData{newBoxZone(
+			PointData{Lat: origin.Lat, Lon: origin.Lon, Alt: 0},
+			PointData{Lat: corner.Lat, Lon: corner.Lon, Alt: 100},
+		)},
+		Params: &params,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pitches := []float64{}
+	for i := 1000; i > 0; i-- {
+		d := float64(i) / 10
+		pos := origin.Translate(100, 200-d, 50)
+		pos.Vx = speed
+
+		rc, target, _, outside := fence.GetAutoRc(pos, NewRc(0, 0, 1, 0, 0))
+		if outside || target != nil {
+			t.Fatalf("speed %v: outside of the fence at %.1fm of the edge", speed, d)
+		}
+		pitches = append(pitches, rc.Pitch())
+	}
+
+	return pitches
+}
+
+func TestFenceLimitTowardEdge(t *testing.T) {
+	speeds := []float64{0, 2, 5, 10}
+	prevSlowDown := float64(0)
+
+	for _, speed := range speeds {
+		pitches := approachEdge(t, speed)
+
+		slowDown := float64(0)
+		for i, pitch := range pitches {
+			d := float64(len(pitches)-i) / 10
+			if pitch < 1 && slowDown == 0 {
+				slowDown = d
+			}
+			if i > 0 && pitch > pitches[i-1]+1e-9 {
+				t.Errorf("speed %v: limit grows from %.3f to %.3f at %.1fm of the edge", speed, pitches[i-1], pitch, d)
+			}
+		}
+
+		if slowDown <= prevSlowDown {
+			t.Errorf("speed %v: slows down at %.1fm of the edge, not earlier than %.1fm at a lower speed", speed, slowDown, prevSlowDown)
+		}
+		prevSlowDown = slowDown
+
+		if last := pitches[len(pitches)-1]; math.Abs(last) > 0.01 {
+			t.Errorf("speed %v: limit is %.3f at 0.1m of the edge, should be 0", speed, last)
+		}
+	}
+}
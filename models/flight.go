@@ -0,0 +1,76 @@
+package models
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// Flight is a vehicle flight, from the moment it
+// is armed to the moment it is disarmed
+type Flight struct {
+	ID           string       `json:"id"`
+	VehicleID    string       `json:"vehicleID"`
+	Pilot        string       `json:"pilot"`
+	Start        time.Time    `json:"start"`
+	End          time.Time    `json:"end"`
+	MaxAltitude  float64      `json:"maxAltitude"`
+	Distance     float64      `json:"distance"`
+	BatteryStart float64      `json:"batteryStart"`
+	BatteryEnd   float64      `json:"batteryEnd"`
+	BatteryUsed  float64      `json:"batteryUsed"`
+	FenceEvents  []FenceEvent `json:"fenceEvents"`
+
+	lastPos    *Position
+	hasBattery bool
+}
+
+// NewFlight starts a new flight
+func NewFlight(vehicleID, pilot string) *Flight {
+	start := time.Now()
+
+	return &Flight{
+		ID:          strconv.FormatInt(start.UnixNano(), 10),
+		VehicleID:   vehicleID,
+		Pilot:       pilot,
+		Start:       start,
+		FenceEvents: []FenceEvent{},
+	}
+}
+
+// SetPilot sets the pilot of the flight if it was flown without pilot so far
+func (f *Flight) SetPilot(pilot string) {
+	if f.Pilot == "" {
+		f.Pilot = pilot
+	}
+}
+
+// AddPosition updates the altitude and distance flown
+func (f *Flight) AddPosition(pos Position) {
+	if f.lastPos == nil {
+		f.MaxAltitude = pos.RelAlt
+	} else {
+		x, y := localXY(*f.lastPos, pos)
+		z := pos.RelAlt - f.lastPos.RelAlt
+		f.Distance += math.Sqrt(x*x + y*y + z*z)
+		f.MaxAltitude = math.Max(f.MaxAltitude, pos.RelAlt)
+	}
+
+	f.lastPos = &pos
+}
+
+// SetBattery updates the battery used during the flight
+func (f *Flight) SetBattery(batt Battery) {
+	if !f.hasBattery {
+		f.BatteryStart = batt.Percent
+		f.hasBattery = true
+	}
+
+	f.BatteryEnd = batt.Percent
+	f.BatteryUsed = f.BatteryStart - f.BatteryEnd
+}
+
+// Finish ends the flight
+func (f *Flight) Finish() {
+	f.End = time.Now()
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Waypoint is a position a vehicle flies to during a mission
+type Waypoint struct {
+	Lat    float64  `json:"lat"`
+	Lon    float64  `json:"lon"`
+	RelAlt float64  `json:"relAlt"`           // Altitude above the launch point in meters
+	Speed  float64  `json:"speed"`            // Speed to reach the waypoint in m/s, 0 for the vehicle's default
+	Hold   float64  `json:"hold"`             // Time spent at the waypoint in seconds
+	Gimbal *float64 `json:"gimbal,omitempty"` // Gimbal pitch set at the waypoint in degrees
+}
+
+// Mission is an ordered list of waypoints
+type Mission struct {
+	Name      string     `json:"name"`
+	Waypoints []Waypoint `json:"waypoints"`
+}
+
+// Mission states
+const (
+	MissionRunning   MissionState = "running"
+	MissionPaused    MissionState = "paused"
+	MissionCompleted MissionState = "completed"
+	MissionAborted   MissionState = "aborted"
+	MissionFailed    MissionState = "failed"
+)
+
+// MissionState is the state of a mission run
+type MissionState string
+
+// MissionProgress describes where a vehicle is in its mission
+type MissionProgress struct {
+	Name    string       `json:"name"`
+	State   MissionState `json:"state"`
+	Index   int          `json:"index"` // Index of the waypoint the vehicle flies to
+	Total   int          `json:"total"`
+	Message string       `json:"message,omitempty"`
+}
+
+// VehicleMission is a mission to run on a vehicle, given
+// directly or as the ID of a stored mission plan
+type VehicleMission struct {
+	VehicleID string  `json:"vehicleID"`
+	Mission   Mission `json:"mission"`
+	PlanID    string  `json:"planID,omitempty"`
+}
+
+// WaypointError is an error on a mission waypoint,
+// an index of -1 concerns the whole mission
+type WaypointError struct {
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
+
+func (e WaypointError) Error() string {
+	if e.Index < 0 {
+		return e.Message
+	}
+
+	return fmt.Sprintf("waypoint %d: %s", e.Index, e.Message)
+}
+
+// WaypointErrors is the list of errors of a mission
+type WaypointErrors []WaypointError
+
+func (errs WaypointErrors) Error() string {
+	msgs := []string{}
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, ", ")
+}
+
+// missionStep is the distance in meters between the points
+// of a leg checked against the fence and no fly zones
+var missionStep = float64(5)
+
+// Position returns the position of the waypoint
+func (w Waypoint) Position() Position {
+	return NewPoint(w.Lat, w.Lon, w.RelAlt)
+}
+
+// Target returns the goto target of the waypoint
+func (w Waypoint) Target() Target {
+	return Target{Position: w.Position(), Speed: w.Speed}
+}
+
+// Validate checks the waypoint values
+func (w Waypoint) Validate() error {
+	if w.Lat < -90 || w.Lat > 90 || w.Lon < -180 || w.Lon > 180 {
+		return errors.New("invalid coordinates")
+	}
+	if w.Speed < 0 {
+		return errors.New("speed should not be negative")
+	}
+	if w.Hold < 0 {
+		return errors.New("hold should not be negative")
+	}
+
+	return nil
+}
+
+// Validate checks the values of every waypoint
+func (m Mission) Validate() WaypointErrors {
+	errs := WaypointErrors{}
+	if len(m.Waypoints) == 0 {
+		errs = append(errs, WaypointError{-1, "mission has no waypoints"})
+	}
+
+	for i, w := range m.Waypoints {
+		if err := w.Validate(); err != nil {
+			errs = append(errs, WaypointError{i, err.Error()})
+		}
+	}
+
+	return errs
+}
+
+// CheckZones checks that every waypoint and the legs leading to them stay
+// inside the fence and out of the no fly zones, fence and noFly can be nil
+func (m Mission) CheckZones(start *Position, fence *Fence, noFly *NoFlyZones) WaypointErrors {
+	errs := WaypointErrors{}
+	prev := start
+
+	for i, w := range m.Waypoints {
+		pos := w.Position()
+
+		if fence != nil && !fence.Check(pos) {
+			errs = append(errs, WaypointError{i, "outside of the fence"})
+		} else if noFly != nil && !noFly.Check(pos) {
+			errs = append(errs, WaypointError{i, "inside a no fly zone"})
+		} else if prev != nil {
+			if msg := checkLeg(*prev, pos, fence, noFly); msg != "" {
+				errs = append(errs, WaypointError{i, msg})
+			}
+		}
+
+		prev = &pos
+	}
+
+	return errs
+}
+
+// checkLeg checks the points of a leg every missionStep meters
+func checkLeg(from, to Position, fence *Fence, noFly *NoFlyZones) string {
+	x, y := localXY(from, to)
+	steps := int(math.Ceil(math.Hypot(x, y) / missionStep))
+
+	for s := 1; s < steps; s++ {
+		f := float64(s) / float64(steps)
+		pos := NewPoint(
+			from.Lat+(to.Lat-from.Lat)*f,
+			from.Lon+(to.Lon-from.Lon)*f,
+			from.RelAlt+(to.RelAlt-from.RelAlt)*f,
+		)
+
+		if fence != nil && !fence.Check(pos) {
+			return "leg leaves the fence"
+		}
+		if noFly != nil && !noFly.Check(pos) {
+			return "leg crosses a no fly zone"
+		}
+	}
+
+	return ""
+}
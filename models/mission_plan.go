@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// MissionPlan is a stored mission, every save creates a new version
+type MissionPlan struct {
+	Mission
+	ID        string    `json:"id"`
+	Version   int       `json:"version"`
+	VehicleID string    `json:"vehicleID"` // Vehicle the plan is validated against, empty for any vehicle
+	Speed     float64   `json:"speed"`     // Planned speed in m/s of waypoints without speed, 0 for the vehicle's default
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+}
+
+// Check validates the plan against the vehicle's mission limits,
+// its fence and the no fly zones, fence and noFly can be nil
+func (p MissionPlan) Check(settings MissionSettings, fence *Fence, noFly *NoFlyZones) WaypointErrors {
+	errs := p.Validate()
+	if p.Speed < 0 {
+		errs = append(errs, WaypointError{-1, "speed should not be negative"})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	errs = append(errs, p.CheckZones(nil, fence, noFly)...)
+	errs = append(errs, p.checkLimits(settings)...)
+	errs = append(errs, p.checkBattery(settings)...)
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Index < errs[j].Index
+	})
+
+	return errs
+}
+
+// Runnable returns the mission to run, waypoints without
+// speed get the planned speed
+func (p MissionPlan) Runnable() Mission {
+	mission := Mission{Name: p.Name}
+	for _, w := range p.Waypoints {
+		if w.Speed == 0 {
+			w.Speed = p.Speed
+		}
+		mission.Waypoints = append(mission.Waypoints, w)
+	}
+
+	return mission
+}
+
+// checkLimits checks the altitude of every waypoint and its
+// distance from the first one, which is used as the launch point
+func (p MissionPlan) checkLimits(settings MissionSettings) WaypointErrors {
+	errs := WaypointErrors{}
+	home := p.Waypoints[0].Position()
+
+	for i, w := range p.Waypoints {
+		if w.RelAlt < 0 {
+			errs = append(errs, WaypointError{i, "below the launch point"})
+		} else if w.RelAlt > settings.MaxAltitude {
+			errs = append(errs, WaypointError{i, fmt.Sprintf("above the max altitude of %gm", settings.MaxAltitude)})
+		}
+
+		x, y := localXY(home, w.Position())
+		if math.Hypot(x, y) > settings.MaxDistance {
+			errs = append(errs, WaypointError{i, fmt.Sprintf("farther than %gm from the first waypoint", settings.MaxDistance)})
+		}
+	}
+
+	return errs
+}
+
+// checkBattery estimates the flight time to each waypoint and back to the
+// first one, and reports the first waypoint exceeding the battery budget
+func (p MissionPlan) checkBattery(settings MissionSettings) WaypointErrors {
+	budget := settings.Budget()
+	elapsed := float64(0)
+	prev := p.Waypoints[0]
+
+	for i, w := range p.Waypoints {
+		elapsed += legDistance(prev, w)/p.speed(w, settings) + w.Hold
+		if elapsed > budget {
+			return WaypointErrors{{i, fmt.Sprintf("exceeds the battery budget: %.0fs of %.0fs", elapsed, budget)}}
+		}
+		prev = w
+	}
+
+	last := len(p.Waypoints) - 1
+	elapsed += legDistance(prev, p.Waypoints[0]) / settings.Speed
+	if elapsed > budget {
+		return WaypointErrors{{last, fmt.Sprintf("not enough battery to return: %.0fs of %.0fs", elapsed, budget)}}
+	}
+
+	return nil
+}
+
+// speed returns the speed used to reach a waypoint
+func (p MissionPlan) speed(w Waypoint, settings MissionSettings) float64 {
+	if w.Speed > 0 {
+		return w.Speed
+	}
+	if p.Speed > 0 {
+		return p.Speed
+	}
+
+	return settings.Speed
+}
+
+// legDistance returns the distance in meters between two waypoints
+func legDistance(from, to Waypoint) float64 {
+	x, y := localXY(from.Position(), to.Position())
+	return math.Sqrt(x*x + y*y + math.Pow(to.RelAlt-from.RelAlt, 2))
+}
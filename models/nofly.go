@@ -0,0 +1,159 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// NoFlyZones is a set of zones a vehicle must keep out of
+type NoFlyZones struct {
+	zones []Zone
+}
+
+// NoFlyData is a json serializable version of the no fly zones
+type NoFlyData []ZoneData
+
+var noFlyZones = struct {
+	sync.RWMutex
+	zones *NoFlyZones
+}{}
+
+// GetNoFlyZones returns the current no fly zones
+func GetNoFlyZones() *NoFlyZones {
+	noFlyZones.RLock()
+	defer noFlyZones.RUnlock()
+	return noFlyZones.zones
+}
+
+// NewNoFlyZones creates no fly zones from their json representation
+func NewNoFlyZones(data NoFlyData) (*NoFlyZones, error) {
+	noFly := &NoFlyZones{}
+	for i, zoneData := range data {
+		zone, err := NewZone(zoneData)
+		if err != nil {
+			return nil, fmt.Errorf("no fly zone %d: %v", i, err)
+		}
+		noFly.zones = append(noFly.zones, zone)
+	}
+
+	return noFly, nil
+}
+
+// SetNoFlyZones sets the current no fly zones,
+// an empty list removes every zone
+func SetNoFlyZones(data NoFlyData) error {
+	noFly, err := NewNoFlyZones(data)
+	if err != nil {
+		return err
+	}
+
+	if len(noFly.zones) == 0 {
+		noFly = nil
+	}
+
+	noFlyZones.Lock()
+	noFlyZones.zones = noFly
+	noFlyZones.Unlock()
+
+	return nil
+}
+
+// Check checks if the provided position is outside every no fly zone
+func (nf *NoFlyZones) Check(pos Position) bool {
+	for _, zone := range nf.zones {
+		if zone.Contains(pos) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetAutoRc returns the corrected rc values to not enter the no fly zones
+// using the tuning parameters of the vehicle's fence
+func (nf *NoFlyZones) GetAutoRc(pos Position, manualRc *Rc, fence *Fence) (*Rc, *Position, bool, bool) {
+	var autoRc *Rc
+	var slowed, outside bool
+	var target *Position
+
+	params := fence.Params()
+
+	if !nf.Check(pos) {
+		outside = true
+		target = &Position{}
+		*target = nf.recoveryTarget(pos, fence)
+		autoRc = NewNullRc()
+	} else {
+		autoRc = manualRc.Copy()
+
+		limits := nf.limits(pos, params)
+
+		autoRc.Rotate(-pos.Hdg)
+		slowed = autoRc.ApplyLimits(limits)
+		autoRc.Rotate(pos.Hdg)
+	}
+
+	return autoRc, target, slowed, outside
+}
+
+// limits returns the rc limits to apply when the vehicle gets
+// close to a no fly zone or moves fast toward it
+func (nf *NoFlyZones) limits(pos Position, params FenceParams) RcLimits {
+	inf := math.Inf(1)
+	east, west, north, south, up, down := inf, inf, inf, inf, inf, inf
+
+	for _, zone := range nf.zones {
+		if zone.InAltitude(pos) {
+			east = math.Min(east, zone.RayDistance(pos, 1, 0))
+			west = math.Min(west, zone.RayDistance(pos, -1, 0))
+			north = math.Min(north, zone.RayDistance(pos, 0, 1))
+			south = math.Min(south, zone.RayDistance(pos, 0, -1))
+		} else if zone.ContainsPoint(pos) {
+			if d := zone.Floor() - pos.RelAlt; d >= 0 {
+				up = math.Min(up, d)
+			}
+			if d := pos.RelAlt - zone.Ceiling(); d >= 0 {
+				down = math.Min(down, d)
+			}
+		}
+	}
+
+	return params.limits(pos, east, west, north, south, up, down)
+}
+
+// recoveryTarget returns a position outside of the no fly zones,
+// close to the provided position, at which the vehicle should be sent,
+// climbing over a zone is only allowed below the fence's ceiling
+func (nf *NoFlyZones) recoveryTarget(pos Position, fence *Fence) Position {
+	pushBack := fence.Params().PushBack
+	target := pos
+
+	for _, zone := range nf.zones {
+		if !zone.Contains(target) {
+			continue
+		}
+
+		x, y, dist := zone.Closest(target)
+		up := zone.Ceiling() - target.RelAlt
+		above := target.Translate(0, 0, up+pushBack)
+
+		if up < dist && fence.Check(above) {
+			target = above
+		} else if dist > 0 {
+			target = target.Translate(x+x/dist*pushBack, y+y/dist*pushBack, 0)
+		}
+	}
+
+	return target
+}
+
+// JSON returns a to JSON convertable representation of the no fly zones
+func (nf *NoFlyZones) JSON() NoFlyData {
+	data := NoFlyData{}
+	for _, zone := range nf.zones {
+		data = append(data, zone.JSON())
+	}
+
+	return data
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+// newNoFlyTest returns a 200m box fence with a 100m ceiling and a 100m
+// wide no fly zone in its middle going from the ground to the given ceiling
+func newNoFlyTest(t *testing.T, ceiling float64) (Position, *Fence, *NoFlyZones) {
+	origin := NewPoint(48.85, 2.35, 0)
+	corner := origin.Translate(200, 200, 0)
+	fence, err := NewFence(FenceData{
+		Zones: []ZoneData{newBoxZone(
+			PointData{Lat: origin.Lat, Lon: origin.Lon, Alt: 0},
+			PointData{Lat: corner.Lat, Lon: corner.Lon, Alt: 100},
+		)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := origin.Translate(50, 50, 0)
+	b := origin.Translate(150, 150, 0)
+	noFly, err := NewNoFlyZones(NoFlyData{newBoxZone(
+		PointData{Lat: a.Lat, Lon: a.Lon, Alt: 0},
+		PointData{Lat: b.Lat, Lon: b.Lon, Alt: ceiling},
+	)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return origin, fence, noFly
+}
+
+// recoverFrom returns the target the vehicle is sent to from inside the no fly zone
+func recoverFrom(t *testing.T, pos Position, fence *Fence, noFly *NoFlyZones) Position {
+	_, target, _, outside := noFly.GetAutoRc(pos, NewNullRc(), fence)
+	if !outside || target == nil {
+		t.Fatal("vehicle inside the no fly zone is not sent out of it")
+	}
+	if !noFly.Check(*target) {
+		t.Error("recovery target is inside the no fly zone")
+	}
+	if !fence.Check(*target) {
+		t.Error("recovery target is outside of the fence")
+	}
+
+	return *target
+}
+
+func TestNoFlyClimbsOverLowZone(t *testing.T) {
+	origin, fence, noFly := newNoFlyTest(t, 30)
+
+	target := recoverFrom(t, origin.Translate(60, 100, 25), fence, noFly)
+	if target.RelAlt <= 30 {
+		t.Errorf("vehicle 5m below the zone's ceiling is sent at %.1fm instead of over it", target.RelAlt)
+	}
+}
+
+func TestNoFlyExitsSidewaysUnderFenceCeiling(t *testing.T) {
+	origin, fence, noFly := newNoFlyTest(t, 98)
+
+	pos := origin.Translate(60, 100, 94)
+	target := recoverFrom(t, pos, fence, noFly)
+	if math.Abs(target.RelAlt-pos.RelAlt) > 0.01 {
+		t.Errorf("vehicle is sent from %.1fm to %.1fm, above the fence's ceiling", pos.RelAlt, target.RelAlt)
+	}
+}
+
+func TestNoFlyLimitsTowardZone(t *testing.T) {
+	origin, fence, noFly := newNoFlyTest(t, 30)
+	pos := origin.Translate(100, 40, 20)
+
+	rc, target, slowed, outside := noFly.GetAutoRc(pos, NewRc(0, 0, 1, 0, 0), fence)
+	if outside || target != nil {
+		t.Fatal("vehicle 10m away from the no fly zone is considered inside")
+	}
+	if !slowed || rc.Pitch() >= 1 {
+		t.Errorf("pitch toward the zone 10m away is %.3f, should be slowed", rc.Pitch())
+	}
+
+	rc, _, _, _ = noFly.GetAutoRc(pos, NewRc(0, 0, -1, 0, 0), fence)
+	if rc.Pitch() != -1 {
+		t.Errorf("pitch away from the zone is limited to %.3f", rc.Pitch())
+	}
+}
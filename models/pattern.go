@@ -0,0 +1,215 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// Orbit circles a point at a constant radius and altitude
+type Orbit struct {
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	Radius    float64 `json:"radius"` // Radius in meters
+	RelAlt    float64 `json:"relAlt"`
+	Speed     float64 `json:"speed"`
+	Laps      int     `json:"laps"` // Number of laps, 1 if not set
+	Clockwise bool    `json:"clockwise"`
+}
+
+// Survey sweeps a polygon with parallel lines, lawnmower style
+type Survey struct {
+	Polygon []PointData `json:"polygon"`
+	Spacing float64     `json:"spacing"` // Distance in meters between two lines
+	Heading float64     `json:"heading"` // Direction of the lines in degrees from north
+	RelAlt  float64     `json:"relAlt"`
+	Speed   float64     `json:"speed"`
+}
+
+// Follow keeps a vehicle at a distance from a moving target
+type Follow struct {
+	Distance float64 `json:"distance"` // Horizontal distance in meters kept from the target
+	RelAlt   float64 `json:"relAlt"`
+	Speed    float64 `json:"speed"`
+}
+
+// VehiclePattern is a flight pattern to run on a vehicle,
+// exactly one of orbit, survey and follow is set
+type VehiclePattern struct {
+	VehicleID string  `json:"vehicleID"`
+	Orbit     *Orbit  `json:"orbit,omitempty"`
+	Survey    *Survey `json:"survey,omitempty"`
+	Follow    *Follow `json:"follow,omitempty"`
+}
+
+// Pattern generation limits
+var (
+	orbitStep        = float64(10) // Max distance in meters between two orbit waypoints
+	orbitMinPoints   = 8
+	orbitMaxLaps     = 100
+	orbitMaxPoints   = 5000
+	surveyMaxLines   = 1000
+	patternMinHeight = float64(1)
+)
+
+// Mission returns the waypoints of the orbit, starting north of the center
+func (o Orbit) Mission() (Mission, error) {
+	if o.Radius <= 0 {
+		return Mission{}, errors.New("radius should be greater than 0")
+	}
+	if o.RelAlt < patternMinHeight {
+		return Mission{}, errors.New("relAlt should be at least 1m")
+	}
+	if o.Speed < 0 {
+		return Mission{}, errors.New("speed should not be negative")
+	}
+
+	laps := o.Laps
+	if laps == 0 {
+		laps = 1
+	}
+	if laps < 0 || laps > orbitMaxLaps {
+		return Mission{}, errors.New("laps should be between 1 and 100")
+	}
+
+	// checked before generating the waypoints, large orbits would exhaust the memory
+	points := math.Max(math.Ceil(2*math.Pi*o.Radius/orbitStep), float64(orbitMinPoints))
+	if points*float64(laps) > float64(orbitMaxPoints) {
+		return Mission{}, errors.New("radius and laps give too many waypoints")
+	}
+	n := int(points)
+
+	dir := float64(-1)
+	if o.Clockwise {
+		dir = 1
+	}
+
+	center := NewPoint(o.Lat, o.Lon, o.RelAlt)
+	mission := Mission{Name: "orbit"}
+	for i := 0; i <= laps*n; i++ {
+		angle := dir * 2 * math.Pi * float64(i) / float64(n)
+		pos := center.Translate(o.Radius*math.Sin(angle), o.Radius*math.Cos(angle), 0)
+		mission.Waypoints = append(mission.Waypoints, Waypoint{
+			Lat:    pos.Lat,
+			Lon:    pos.Lon,
+			RelAlt: o.RelAlt,
+			Speed:  o.Speed,
+		})
+	}
+
+	return mission, nil
+}
+
+// Mission returns the waypoints of the survey, lines cover the polygon's
+// extent and are flown in alternate directions
+func (s Survey) Mission() (Mission, error) {
+	if len(s.Polygon) < 3 {
+		return Mission{}, errors.New("polygon should have at least 3 points")
+	}
+	if s.Spacing <= 0 {
+		return Mission{}, errors.New("spacing should be greater than 0")
+	}
+	if s.RelAlt < patternMinHeight {
+		return Mission{}, errors.New("relAlt should be at least 1m")
+	}
+	if s.Speed < 0 {
+		return Mission{}, errors.New("speed should not be negative")
+	}
+
+	// d is the direction of the lines and n the direction in which they are spaced
+	rad := s.Heading * math.Pi / 180
+	dx, dy := math.Sin(rad), math.Cos(rad)
+	nx, ny := dy, -dx
+
+	ref := NewPoint(s.Polygon[0].Lat, s.Polygon[0].Lon, s.RelAlt)
+	xs := make([]float64, len(s.Polygon))
+	ys := make([]float64, len(s.Polygon))
+	min, max := math.Inf(1), math.Inf(-1)
+	for i, p := range s.Polygon {
+		xs[i], ys[i] = localXY(ref, NewPoint(p.Lat, p.Lon, 0))
+		proj := xs[i]*nx + ys[i]*ny
+		min = math.Min(min, proj)
+		max = math.Max(max, proj)
+	}
+
+	if (max-min)/s.Spacing > float64(surveyMaxLines) {
+		return Mission{}, errors.New("spacing is too small for the polygon")
+	}
+
+	mission := Mission{Name: "survey"}
+	reverse := false
+	for offset := min + s.Spacing/2; offset < max; offset += s.Spacing {
+		along := []float64{}
+		for i := range s.Polygon {
+			j := (i + 1) % len(s.Polygon)
+			si := xs[i]*nx + ys[i]*ny - offset
+			sj := xs[j]*nx + ys[j]*ny - offset
+			if (si < 0) == (sj < 0) {
+				continue
+			}
+
+			t := si / (si - sj)
+			x := xs[i] + (xs[j]-xs[i])*t
+			y := ys[i] + (ys[j]-ys[i])*t
+			along = append(along, x*dx+y*dy)
+		}
+		if len(along) < 2 {
+			continue
+		}
+
+		sort.Float64s(along)
+		ends := []float64{along[0], along[len(along)-1]}
+		if reverse {
+			ends[0], ends[1] = ends[1], ends[0]
+		}
+		reverse = !reverse
+
+		for _, a := range ends {
+			pos := ref.Translate(offset*nx+a*dx, offset*ny+a*dy, 0)
+			mission.Waypoints = append(mission.Waypoints, Waypoint{
+				Lat:    pos.Lat,
+				Lon:    pos.Lon,
+				RelAlt: s.RelAlt,
+				Speed:  s.Speed,
+			})
+		}
+	}
+
+	if len(mission.Waypoints) == 0 {
+		return Mission{}, errors.New("polygon is smaller than the spacing")
+	}
+
+	return mission, nil
+}
+
+// Validate checks the follow values
+func (f Follow) Validate() error {
+	if f.Distance < 0 {
+		return errors.New("distance should not be negative")
+	}
+	if f.RelAlt < patternMinHeight {
+		return errors.New("relAlt should be at least 1m")
+	}
+	if f.Speed < 0 {
+		return errors.New("speed should not be negative")
+	}
+
+	return nil
+}
+
+// Target returns the position to fly to in order to keep
+// the follow distance from target, coming from pos
+func (f Follow) Target(pos *Position, target Position) Target {
+	dest := NewPoint(target.Lat, target.Lon, f.RelAlt)
+	if pos != nil {
+		x, y := localXY(target, *pos)
+		if d := math.Hypot(x, y); d > f.Distance {
+			dest = dest.Translate(x*f.Distance/d, y*f.Distance/d, 0)
+		} else {
+			// already close enough, only keep the altitude
+			dest = NewPoint(pos.Lat, pos.Lon, f.RelAlt)
+		}
+	}
+
+	return Target{Position: dest, Speed: f.Speed}
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOrbitRejectsTooManyWaypoints(t *testing.T) {
+	for _, radius := range []float64{1e5, 1e12, math.Inf(1)} {
+		if _, err := (Orbit{Lat: 48.85, Lon: 2.35, Radius: radius, RelAlt: 20}).Mission(); err == nil {
+			t.Errorf("orbit of radius %v accepted", radius)
+		}
+	}
+
+	if _, err := (Orbit{Lat: 48.85, Lon: 2.35, Radius: 100, RelAlt: 20, Laps: orbitMaxLaps}).Mission(); err == nil {
+		t.Error("orbit of 100 laps of 100m accepted")
+	}
+
+	mission, err := Orbit{Lat: 48.85, Lon: 2.35, Radius: 50, RelAlt: 20, Laps: 2}.Mission()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(mission.Waypoints); n != 2*32+1 {
+		t.Errorf("orbit of 2 laps of 50m has %d waypoints, should be 65", n)
+	}
+}
@@ -2,8 +2,55 @@ package models
 
 type Permissions map[string]bool
 
+// DefaultPermissions returns the permissions granted to new users
+func DefaultPermissions() Permissions {
+	return Permissions{
+		"rc":      true,
+		"goto":    true,
+		"takeoff": true,
+		"land":    true,
+		"rtl":     true,
+		"gimbal":  true,
+		"webrtc":  true,
+		"follow":  true,
+	}
+}
+
+// messagePermissions maps the messages users send to
+// the permission they need, other messages need none
+var messagePermissions = map[string]string{
+	"rc":                  "rc",
+	"goto":                "goto",
+	"takeoff":             "takeoff",
+	"land":                "land",
+	"rtl":                 "rtl",
+	"gimbal":              "gimbal",
+	"webrtc:start":        "webrtc",
+	"webrtc:sdp":          "webrtc",
+	"webrtc:icecandidate": "webrtc",
+	"follow:target":       "follow",
+}
+
+// MessagePermission returns the permission needed to send
+// a message type, false if it does not need any
+func MessagePermission(msgType string) (string, bool) {
+	feature, ok := messagePermissions[msgType]
+	return feature, ok
+}
+
+// AllPermissions returns permissions granting every feature
+func AllPermissions() Permissions {
+	return Permissions{"*": true}
+}
+
+// Allowed checks if a feature is granted, an explicit value for
+// the feature takes precedence over the "*" wildcard
 func (p Permissions) Allowed(feature string) bool {
-	return p[feature]
+	if val, ok := p[feature]; ok {
+		return val
+	}
+
+	return p["*"]
 }
 
 func (p Permissions) Set(feature string, val bool) {
@@ -21,3 +68,17 @@ func (p Permissions) JSON() interface{} {
 
 	return out
 }
+
+// Merge sets every feature value of other into p
+func (p Permissions) Merge(other Permissions) {
+	for feature, val := range other {
+		p[feature] = val
+	}
+}
+
+// Copy returns a copy of the permissions
+func (p Permissions) Copy() Permissions {
+	out := Permissions{}
+	out.Merge(p)
+	return out
+}
@@ -0,0 +1,30 @@
+package models
+
+import "testing"
+
+func TestMessagePermissions(t *testing.T) {
+	defaults := DefaultPermissions()
+
+	for msgType := range messagePermissions {
+		feature, ok := MessagePermission(msgType)
+		if !ok || !defaults.Allowed(feature) {
+			t.Errorf("'%v' needs permission '%v' not granted to new users", msgType, feature)
+		}
+	}
+
+	if feature, ok := MessagePermission("ping"); ok {
+		t.Errorf("'ping' needs permission '%v'", feature)
+	}
+}
+
+func TestPermissionsWildcard(t *testing.T) {
+	p := AllPermissions()
+	p.Set("rc", false)
+
+	if p.Allowed("rc") {
+		t.Error("revoked permission granted by the wildcard")
+	}
+	if !p.Allowed("goto") {
+		t.Error("permission not granted by the wildcard")
+	}
+}
@@ -55,6 +55,18 @@ func NewRcLimits() RcLimits {
 	return RcLimits{1, -1, 1, -1, 1, -1}
 }
 
+// Merge restricts the limits to the most restrictive values of both
+func (limits RcLimits) Merge(other RcLimits) RcLimits {
+	return RcLimits{
+		RollMax:     math.Min(limits.RollMax, other.RollMax),
+		RollMin:     math.Max(limits.RollMin, other.RollMin),
+		PitchMax:    math.Min(limits.PitchMax, other.PitchMax),
+		PitchMin:    math.Max(limits.PitchMin, other.PitchMin),
+		ThrottleMax: math.Min(limits.ThrottleMax, other.ThrottleMax),
+		ThrottleMin: math.Max(limits.ThrottleMin, other.ThrottleMin),
+	}
+}
+
 // Rc represents radio control pwm values
 // to be sent to a vehicle
 type Rc struct {
@@ -67,6 +79,15 @@ type Rc struct {
 	updated  time.Time    `json:"-"`
 }
 
+// RcData is a json serializable version of the rc struct
+type RcData struct {
+	Throttle float64 `json:"throttle"`
+	Roll     float64 `json:"roll"`
+	Pitch    float64 `json:"pitch"`
+	Yaw      float64 `json:"yaw"`
+	Gimbal   float64 `json:"gimbal"`
+}
+
 // NewNullRc creates and returns a new Rc struct with
 // every value set to zero
 func NewNullRc() *Rc {
@@ -101,6 +122,20 @@ func (rc *Rc) Copy() *Rc {
 	return new
 }
 
+// Data returns a to JSON convertable copy of the rc values
+func (rc *Rc) Data() RcData {
+	rc.lock.RLock()
+	defer rc.lock.RUnlock()
+
+	return RcData{
+		Throttle: rc.throttle,
+		Roll:     rc.roll,
+		Pitch:    rc.pitch,
+		Yaw:      rc.yaw,
+		Gimbal:   rc.gimbal,
+	}
+}
+
 // Set copies rc data into this one
 func (rc *Rc) Set(other *Rc) {
 	rc.lock.Lock()
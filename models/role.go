@@ -0,0 +1,30 @@
+package models
+
+// Role defines what an admin is allowed to do
+type Role string
+
+const (
+	// RoleViewer can only watch vehicles and read their data
+	RoleViewer Role = "viewer"
+	// RoleOperator can additionally operate vehicles and pilots
+	RoleOperator Role = "operator"
+	// RoleSupervisor can additionally change fences, settings and accounts
+	RoleSupervisor Role = "supervisor"
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer:     1,
+	RoleOperator:   2,
+	RoleSupervisor: 3,
+}
+
+// Valid checks if the role exists
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Includes checks if the role grants at least the rights of other
+func (r Role) Includes(other Role) bool {
+	return roleRanks[r] >= roleRanks[other]
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"errors"
+	"math"
+)
+
+// Separation actions
+const (
+	SeparationLimit = "limit" // The rc of the vehicles is limited toward each other
+	SeparationHold  = "hold"  // The vehicles hold their position
+)
+
+// SeparationSettings configures the minimum separation between vehicles
+type SeparationSettings struct {
+	Horizontal float64 `json:"horizontal"` // Minimum horizontal distance in meters
+	Vertical   float64 `json:"vertical"`   // Minimum vertical distance in meters
+	Horizon    float64 `json:"horizon"`    // Time in seconds within which a predicted violation is a conflict
+	Action     string  `json:"action"`     // Action taken on a conflict: "limit" or "hold"
+}
+
+// DefaultSeparationSettings returns the separation settings
+// used when they were not configured
+func DefaultSeparationSettings() SeparationSettings {
+	return SeparationSettings{
+		Horizontal: 15,
+		Vertical:   5,
+		Horizon:    5,
+		Action:     SeparationLimit,
+	}
+}
+
+// Validate checks the separation settings values
+func (s SeparationSettings) Validate() error {
+	if s.Horizontal <= 0 || s.Vertical <= 0 {
+		return errors.New("minima should be greater than 0")
+	}
+	if s.Horizon < 0 {
+		return errors.New("horizon should not be negative")
+	}
+	if s.Action != SeparationLimit && s.Action != SeparationHold {
+		return errors.New("action should be limit or hold")
+	}
+
+	return nil
+}
+
+// Separation describes the relative position and motion of two vehicles
+type Separation struct {
+	VehicleIDs   [2]string `json:"vehicleIDs"`
+	Horizontal   float64   `json:"horizontal"`   // Current horizontal distance in meters
+	Vertical     float64   `json:"vertical"`     // Current vertical distance in meters
+	ClosingSpeed float64   `json:"closingSpeed"` // Speed in m/s at which the vehicles get closer, negative when they move apart
+	Violated     bool      `json:"violated"`     // Both minima are currently violated
+	Conflict     bool      `json:"conflict"`     // Both minima are violated now or within the horizon
+
+	// direction from the first vehicle to the second one, x east,
+	// y north and z up, and vertical closing speed, used to limit the rc
+	x, y, z  float64
+	vClosing float64
+}
+
+// NewSeparation computes the separation between two vehicles from their
+// positions, altitudes are compared above sea level since vehicles
+// may have taken off from different places
+func NewSeparation(idA string, a Position, idB string, b Position, settings SeparationSettings) Separation {
+	// positions are x east, y north and z up, velocities are north east down
+	x, y := localXY(a, b)
+	z := b.Alt - a.Alt
+	vx, vy, vz := b.Vy-a.Vy, b.Vx-a.Vx, a.Vz-b.Vz
+
+	s := Separation{
+		VehicleIDs: [2]string{idA, idB},
+		Horizontal: math.Hypot(x, y),
+		Vertical:   math.Abs(z),
+		x:          x,
+		y:          y,
+		z:          z,
+	}
+	if s.Horizontal > 0 {
+		s.ClosingSpeed = -(x*vx + y*vy) / s.Horizontal
+	}
+	if s.Vertical > 0 {
+		s.vClosing = -z * vz / s.Vertical
+	}
+
+	s.Violated = s.Horizontal < settings.Horizontal && s.Vertical < settings.Vertical
+
+	// closest point of approach within the horizon
+	t := float64(0)
+	if v2 := vx*vx + vy*vy + vz*vz; v2 > 0 {
+		t = math.Max(0, math.Min(settings.Horizon, -(x*vx+y*vy+z*vz)/v2))
+	}
+	h := math.Hypot(x+vx*t, y+vy*t)
+	v := math.Abs(z + vz*t)
+
+	s.Conflict = s.Violated || (h < settings.Horizontal && v < settings.Vertical)
+
+	return s
+}
+
+// Involves checks if a vehicle is one of the two vehicles
+func (s Separation) Involves(vehicleID string) bool {
+	return s.VehicleIDs[0] == vehicleID || s.VehicleIDs[1] == vehicleID
+}
+
+// Limits returns the rc limits preventing a vehicle of the pair from
+// getting closer to the other one, in the east north up frame
+func (s Separation) Limits(vehicleID string, settings SeparationSettings) RcLimits {
+	if settings.Action == SeparationHold {
+		return RcLimits{}
+	}
+
+	x, y, z := s.x, s.y, s.z
+	if vehicleID == s.VehicleIDs[1] {
+		x, y, z = -x, -y, -z
+	}
+
+	limits := NewRcLimits()
+
+	// rc toward the other vehicle is scaled down to 0 at the minimum distance
+	if s.Horizontal > 0 {
+		brake := 1 - separationLimit(s.Horizontal, settings.Horizontal, s.ClosingSpeed, settings.Horizon)
+		if east := x / s.Horizontal; east > 0 {
+			limits.RollMax = 1 - east*brake
+		} else {
+			limits.RollMin = -1 - east*brake
+		}
+		if north := y / s.Horizontal; north > 0 {
+			limits.PitchMax = 1 - north*brake
+		} else {
+			limits.PitchMin = -1 - north*brake
+		}
+	}
+
+	vLimit := separationLimit(s.Vertical, settings.Vertical, s.vClosing, settings.Horizon)
+	if z > 0 {
+		limits.ThrottleMax = vLimit
+	} else {
+		limits.ThrottleMin = -vLimit
+	}
+
+	return limits
+}
+
+// separationLimit returns the maximum rc value toward a vehicle at distance d
+// with a minimum m while getting closer at speed v, like the fence's limit
+func separationLimit(d, m, v, horizon float64) float64 {
+	limit := math.Max(0, math.Min(1, (d-m)/m))
+
+	// brake according to the time left before reaching the minimum
+	if v > 0 && horizon > 0 {
+		limit = math.Max(0, math.Min(limit, (d-m)/v/horizon))
+	}
+
+	return limit
+}
@@ -0,0 +1,16 @@
+package models
+
+import "math"
+
+// Target is a goto destination with an optional speed
+type Target struct {
+	Position
+	Speed float64 `json:"speed,omitempty"` // Speed in m/s, 0 for the vehicle's default
+}
+
+// Reached checks if pos is within radius meters horizontally
+// and height meters vertically of the target
+func (t Target) Reached(pos Position, radius, height float64) bool {
+	x, y := localXY(pos, t.Position)
+	return math.Hypot(x, y) <= radius && math.Abs(pos.RelAlt-t.RelAlt) <= height
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Kinds of telemetry samples
+const (
+	SampleStatus   = "status"
+	SamplePosition = "position"
+	SampleBattery  = "battery"
+)
+
+// TelemetrySample is a telemetry value received from a vehicle at a given time,
+// only the field matching its kind is set
+type TelemetrySample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Status    *Status   `json:"status,omitempty"`
+	Position  *Position `json:"position,omitempty"`
+	Battery   *Battery  `json:"battery,omitempty"`
+}
+
+// Kind returns the kind of telemetry held by the sample
+func (s TelemetrySample) Kind() string {
+	switch {
+	case s.Status != nil:
+		return SampleStatus
+	case s.Position != nil:
+		return SamplePosition
+	case s.Battery != nil:
+		return SampleBattery
+	}
+
+	return ""
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"sync"
 
 	"github.com/volons/hive/libs"
@@ -27,12 +28,88 @@ func NewUser(id, token, vehicleID string) *User {
 		token:       token,
 		done:        libs.NewDone(),
 		lock:        &sync.RWMutex{},
-		permissions: Permissions{},
+		permissions: DefaultPermissions(),
 	}
 }
 
+// userJSON is the json serializable version of the user struct,
+// the token is left out since users are sent to clients
+type userJSON struct {
+	ID          string      `json:"id"`
+	VehicleID   string      `json:"vehicleID"`
+	Name        string      `json:"name"`
+	Permissions Permissions `json:"permissions"`
+}
+
+// MarshalJSON encodes the user into json
+func (u *User) MarshalJSON() ([]byte, error) {
+	return json.Marshal(userJSON{
+		ID:          u.ID(),
+		VehicleID:   u.VehicleID(),
+		Name:        u.Name(),
+		Permissions: u.Permissions(),
+	})
+}
+
+// UnmarshalJSON decodes a user from json
+func (u *User) UnmarshalJSON(data []byte) error {
+	var val userJSON
+	err := json.Unmarshal(data, &val)
+	if err != nil {
+		return err
+	}
+
+	*u = *NewUser(val.ID, "", val.VehicleID)
+	u.name = val.Name
+	if val.Permissions != nil {
+		u.permissions = val.Permissions
+	}
+
+	return nil
+}
+
+// UserRecord is the stored version of a user, it keeps the
+// token, the permissions are stored apart since they do not expire
+type UserRecord struct {
+	ID        string `json:"id"`
+	Token     string `json:"token"`
+	VehicleID string `json:"vehicleID"`
+	Name      string `json:"name"`
+}
+
+// Record returns the user's stored version
+func (u *User) Record() UserRecord {
+	return UserRecord{
+		ID:        u.ID(),
+		Token:     u.Token(),
+		VehicleID: u.VehicleID(),
+		Name:      u.Name(),
+	}
+}
+
+// User creates the user from its stored version
+// with the default permissions
+func (r UserRecord) User() *User {
+	u := NewUser(r.ID, r.Token, r.VehicleID)
+	u.name = r.Name
+
+	return u
+}
+
+// Permissions returns a copy of the user's permissions
 func (u *User) Permissions() Permissions {
-	return u.permissions
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	return u.permissions.Copy()
+}
+
+// SetPermissions grants or revokes the features
+// present in perms and keeps the other ones
+func (u *User) SetPermissions(perms Permissions) {
+	u.lock.Lock()
+	u.permissions.Merge(perms)
+	u.lock.Unlock()
 }
 
 // Name returns the user's name
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // A Vehicle is instantiated for each connected vehicle
 //type Vehicle interface {
 //	ID() string
@@ -38,7 +40,7 @@ type Vehicle struct {
 	token string `json:"-"`
 	Name  string `json:"name"`
 	Model string `json:"model"`
-	Caps  Caps   `json:"-"`
+	Caps  Caps   `json:"caps"`
 }
 
 func NewVehicle(id, token, model string, caps Caps) *Vehicle {
@@ -49,3 +51,46 @@ func NewVehicle(id, token, model string, caps Caps) *Vehicle {
 		Caps:  caps,
 	}
 }
+
+// VehicleInfo describes a known vehicle and its connection state
+type VehicleInfo struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Model    string    `json:"model"`
+	Caps     Caps      `json:"caps"` // null if the vehicle never declared them
+	Online   bool      `json:"online"`
+	LastSeen time.Time `json:"lastSeen"`
+	Pilot    string    `json:"pilot"`
+}
+
+// Info returns the description of the vehicle
+func (v *Vehicle) Info() VehicleInfo {
+	return VehicleInfo{
+		ID:    v.ID,
+		Name:  v.Name,
+		Model: v.Model,
+		Caps:  v.Caps,
+	}
+}
+
+// VehicleCredentials holds the secret a provisioned vehicle uses to authenticate
+type VehicleCredentials struct {
+	ID         string    `json:"id"`
+	SecretHash string    `json:"secretHash"`
+	Created    time.Time `json:"created"`
+}
+
+// NewVehicleCredentials creates credentials for a vehicle,
+// only a hash of the secret is kept
+func NewVehicleCredentials(id, secret string) VehicleCredentials {
+	return VehicleCredentials{
+		ID:         id,
+		SecretHash: hashKey(secret),
+		Created:    time.Now(),
+	}
+}
+
+// Check checks if the secret matches the vehicle's credentials
+func (c VehicleCredentials) Check(secret string) bool {
+	return checkKey(c.SecretHash, secret)
+}
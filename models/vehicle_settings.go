@@ -0,0 +1,171 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// VehicleSettings holds the configuration of a vehicle
+type VehicleSettings struct {
+	Session  SessionSettings  `json:"session"`
+	Battery  BatterySettings  `json:"battery"`
+	Failsafe FailsafeSettings `json:"failsafe"`
+	Mission  MissionSettings  `json:"mission"`
+}
+
+// SessionSettings configures the pilot sessions of a vehicle
+type SessionSettings struct {
+	Duration  float64 `json:"duration"`  // Duration of a session in seconds
+	EndAction string  `json:"endAction"` // Command sent to the vehicle when a session ends: "land" or "rtl"
+}
+
+// DefaultVehicleSettings returns the settings used
+// for vehicles that were not configured
+func DefaultVehicleSettings() VehicleSettings {
+	return VehicleSettings{
+		Session: SessionSettings{
+			Duration:  180,
+			EndAction: "rtl",
+		},
+		Battery: BatterySettings{
+			Warning:    30,
+			Critical:   20,
+			Emergency:  10,
+			Hysteresis: 3,
+		},
+		Failsafe: FailsafeSettings{
+			Hold:      3,
+			RTL:       30,
+			Telemetry: 5,
+		},
+		Mission: MissionSettings{
+			MaxDistance: 500,
+			MaxAltitude: 120,
+			Speed:       5,
+			Endurance:   1200,
+			Reserve:     30,
+		},
+	}
+}
+
+// Validate checks the session settings values
+func (s SessionSettings) Validate() error {
+	if s.Duration <= 0 {
+		return errors.New("duration should be greater than 0")
+	}
+	if s.EndAction != "land" && s.EndAction != "rtl" {
+		return errors.New("endAction should be land or rtl")
+	}
+
+	return nil
+}
+
+// DurationTime returns the duration of a session as a time.Duration
+func (s SessionSettings) DurationTime() time.Duration {
+	return time.Duration(s.Duration * float64(time.Second))
+}
+
+// BatterySettings configures the battery failsafe of a vehicle,
+// thresholds are battery percentages
+type BatterySettings struct {
+	Warning    float64 `json:"warning"`    // The pilot and admins are warned
+	Critical   float64 `json:"critical"`   // The vehicle is forced to return to launch
+	Emergency  float64 `json:"emergency"`  // The vehicle is forced to land
+	Hysteresis float64 `json:"hysteresis"` // Recovery needed to leave a level
+}
+
+// Validate checks the battery settings values
+func (s BatterySettings) Validate() error {
+	if s.Emergency < 0 || s.Warning > 100 {
+		return errors.New("thresholds should be between 0 and 100")
+	}
+	if s.Emergency >= s.Critical || s.Critical >= s.Warning {
+		return errors.New("thresholds should be ordered: emergency < critical < warning")
+	}
+	if s.Hysteresis < 0 {
+		return errors.New("hysteresis should not be negative")
+	}
+
+	return nil
+}
+
+// Level returns the battery level matching a percentage, a level
+// is only left once the percentage recovers above its threshold
+// by more than the hysteresis so that noisy readings do not flap
+func (s BatterySettings) Level(percent float64, current BatteryLevel) BatteryLevel {
+	level := BatteryOK
+	for i, threshold := range []float64{s.Warning, s.Critical, s.Emergency} {
+		l := BatteryLevel(i + 1)
+		if l <= current {
+			threshold += s.Hysteresis
+		}
+		if percent <= threshold {
+			level = l
+		}
+	}
+
+	return level
+}
+
+// FailsafeSettings configures the link loss failsafe of a vehicle, in seconds
+type FailsafeSettings struct {
+	Hold      float64 `json:"hold"`      // The vehicle holds its position without pilot input
+	RTL       float64 `json:"rtl"`       // The vehicle returns to launch without pilot input
+	Telemetry float64 `json:"telemetry"` // The vehicle lands without telemetry
+}
+
+// Validate checks the failsafe settings values
+func (s FailsafeSettings) Validate() error {
+	if s.Hold <= 0 || s.Telemetry <= 0 {
+		return errors.New("delays should be greater than 0")
+	}
+	if s.RTL <= s.Hold {
+		return errors.New("rtl should be greater than hold")
+	}
+
+	return nil
+}
+
+// HoldTime returns the hold delay as a time.Duration
+func (s FailsafeSettings) HoldTime() time.Duration {
+	return time.Duration(s.Hold * float64(time.Second))
+}
+
+// RTLTime returns the rtl delay as a time.Duration
+func (s FailsafeSettings) RTLTime() time.Duration {
+	return time.Duration(s.RTL * float64(time.Second))
+}
+
+// TelemetryTime returns the telemetry timeout as a time.Duration
+func (s FailsafeSettings) TelemetryTime() time.Duration {
+	return time.Duration(s.Telemetry * float64(time.Second))
+}
+
+// MissionSettings configures the limits mission plans are validated against
+type MissionSettings struct {
+	MaxDistance float64 `json:"maxDistance"` // Max distance in meters from the first waypoint
+	MaxAltitude float64 `json:"maxAltitude"` // Max altitude in meters above the launch point
+	Speed       float64 `json:"speed"`       // Default speed of the vehicle in m/s
+	Endurance   float64 `json:"endurance"`   // Flight time in seconds on a full battery
+	Reserve     float64 `json:"reserve"`     // Battery percentage that should be left at the end of a mission
+}
+
+// Validate checks the mission settings values
+func (s MissionSettings) Validate() error {
+	if s.MaxDistance <= 0 || s.MaxAltitude <= 0 {
+		return errors.New("max distance and altitude should be greater than 0")
+	}
+	if s.Speed <= 0 || s.Endurance <= 0 {
+		return errors.New("speed and endurance should be greater than 0")
+	}
+	if s.Reserve < 0 || s.Reserve >= 100 {
+		return errors.New("reserve should be between 0 and 100")
+	}
+
+	return nil
+}
+
+// Budget returns the flight time in seconds available to a mission
+func (s MissionSettings) Budget() float64 {
+	return s.Endurance * (100 - s.Reserve) / 100
+}
@@ -0,0 +1,30 @@
+package models
+
+import "testing"
+
+func TestBatteryLevelHysteresis(t *testing.T) {
+	settings := DefaultVehicleSettings().Battery
+
+	tests := []struct {
+		percent float64
+		current BatteryLevel
+		level   BatteryLevel
+	}{
+		{50, BatteryOK, BatteryOK},
+		{30, BatteryOK, BatteryWarning},
+		{32, BatteryWarning, BatteryWarning},
+		{34, BatteryWarning, BatteryOK},
+		{19, BatteryOK, BatteryCritical},
+		{22, BatteryCritical, BatteryCritical},
+		{22, BatteryWarning, BatteryWarning},
+		{24, BatteryCritical, BatteryWarning},
+		{5, BatteryOK, BatteryEmergency},
+		{12, BatteryEmergency, BatteryEmergency},
+	}
+
+	for _, test := range tests {
+		if level := settings.Level(test.percent, test.current); level != test.level {
+			t.Errorf("battery level %v at %.0f%% from %v, should be %v", level, test.percent, test.current, test.level)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"errors"
+	"math"
+)
+
+// Zone is a polygonal area limited by an altitude floor and ceiling
+type Zone struct {
+	points  []Position
+	floor   float64
+	ceiling float64
+}
+
+// ZoneData is a json serializable version of the zone struct
+type ZoneData struct {
+	Points  []PointData `json:"points"`
+	Floor   float64     `json:"floor"`
+	Ceiling float64     `json:"ceiling"`
+}
+
+// NewZone creates a zone from its json representation
+func NewZone(data ZoneData) (Zone, error) {
+	zone := Zone{
+		floor:   data.Floor,
+		ceiling: data.Ceiling,
+	}
+
+	for _, p := range data.Points {
+		zone.points = append(zone.points, NewPoint(p.Lat, p.Lon, 0))
+	}
+
+	if !zone.Valid() {
+		return Zone{}, errors.New("zone is not valid")
+	}
+
+	return zone, nil
+}
+
+// newBoxZone creates a zone from two opposite corners
+func newBoxZone(a, b PointData) ZoneData {
+	return ZoneData{
+		Points: []PointData{
+			{Lat: a.Lat, Lon: a.Lon},
+			{Lat: a.Lat, Lon: b.Lon},
+			{Lat: b.Lat, Lon: b.Lon},
+			{Lat: b.Lat, Lon: a.Lon},
+		},
+		Floor:   a.Alt,
+		Ceiling: b.Alt,
+	}
+}
+
+// Valid checks if the zone is a polygon with
+// a non zero area and a floor below its ceiling
+func (z Zone) Valid() bool {
+	if len(z.points) < 3 || z.floor >= z.ceiling {
+		return false
+	}
+
+	var area float64
+	ref := z.points[0]
+	for i := range z.points {
+		ax, ay := localXY(ref, z.points[i])
+		bx, by := localXY(ref, z.points[(i+1)%len(z.points)])
+		area += ax*by - bx*ay
+	}
+
+	return math.Abs(area) > 1e-6
+}
+
+// Floor returns the minimum relative altitude of the zone
+func (z Zone) Floor() float64 {
+	return z.floor
+}
+
+// Ceiling returns the maximum relative altitude of the zone
+func (z Zone) Ceiling() float64 {
+	return z.ceiling
+}
+
+// Contains checks if the position is inside the polygon
+// and between the floor and the ceiling of the zone
+func (z Zone) Contains(pos Position) bool {
+	return z.ContainsPoint(pos) && z.InAltitude(pos)
+}
+
+// InAltitude checks if the relative altitude of the position
+// is between the floor and the ceiling of the zone
+func (z Zone) InAltitude(pos Position) bool {
+	return pos.RelAlt >= z.floor && pos.RelAlt <= z.ceiling
+}
+
+// ContainsPoint checks if the position is inside the polygon
+// regardless of its altitude
+func (z Zone) ContainsPoint(pos Position) bool {
+	inside := false
+
+	for i, j := 0, len(z.points)-1; i < len(z.points); j, i = i, i+1 {
+		ax, ay := localXY(pos, z.points[i])
+		bx, by := localXY(pos, z.points[j])
+
+		if (ay > 0) != (by > 0) && 0 < (bx-ax)*(0-ay)/(by-ay)+ax {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// RayDistance returns the distance in meters from the position
+// to the first edge of the polygon crossed when moving in the
+// (east, north) direction, or +Inf if no edge is crossed
+func (z Zone) RayDistance(pos Position, east, north float64) float64 {
+	dist := math.Inf(1)
+
+	for i := range z.points {
+		ax, ay := localXY(pos, z.points[i])
+		bx, by := localXY(pos, z.points[(i+1)%len(z.points)])
+		ex, ey := bx-ax, by-ay
+
+		denom := cross(east, north, ex, ey)
+		if math.Abs(denom) < 1e-12 {
+			continue
+		}
+
+		t := cross(ax, ay, ex, ey) / denom
+		s := cross(ax, ay, east, north) / denom
+		if t >= 0 && s >= 0 && s <= 1 && t < dist {
+			dist = t
+		}
+	}
+
+	return dist
+}
+
+// Closest returns the offset in meters (east, north) from the position
+// to the closest point on the outline of the polygon and its distance
+func (z Zone) Closest(pos Position) (x, y, dist float64) {
+	dist = math.Inf(1)
+
+	for i := range z.points {
+		ax, ay := localXY(pos, z.points[i])
+		bx, by := localXY(pos, z.points[(i+1)%len(z.points)])
+		ex, ey := bx-ax, by-ay
+
+		var s float64
+		if l := ex*ex + ey*ey; l > 0 {
+			s = math.Max(0, math.Min(1, -(ax*ex+ay*ey)/l))
+		}
+
+		px, py := ax+s*ex, ay+s*ey
+		if d := math.Hypot(px, py); d < dist {
+			x, y, dist = px, py, d
+		}
+	}
+
+	return x, y, dist
+}
+
+// JSON returns a to JSON convertable representation of this zone
+func (z Zone) JSON() ZoneData {
+	data := ZoneData{
+		Points:  []PointData{},
+		Floor:   z.floor,
+		Ceiling: z.ceiling,
+	}
+
+	for _, p := range z.points {
+		data.Points = append(data.Points, PointData{p.Lat, p.Lon, 0})
+	}
+
+	return data
+}
+
+// localXY returns the offset in meters (east, north)
+// of p relative to the ref position
+func localXY(ref Position, p Position) (x, y float64) {
+	rEarth := float64(6371000)
+	rad := math.Pi / 180
+
+	x = (p.Lon - ref.Lon) * rad * math.Cos(ref.Lat*rad) * rEarth
+	y = (p.Lat - ref.Lat) * rad * rEarth
+
+	return x, y
+}
+
+func cross(ax, ay, bx, by float64) float64 {
+	return ax*by - ay*bx
+}
@@ -1,14 +1,15 @@
 package vehicle
 
 import (
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/volons/hive/libs"
 	"github.com/volons/hive/libs/autopilot"
 	"github.com/volons/hive/libs/callback"
-	"github.com/volons/hive/libs/db"
 	"github.com/volons/hive/libs/store"
 	"github.com/volons/hive/messages"
 	"github.com/volons/hive/models"
@@ -24,6 +25,39 @@ type Vehicle struct {
 	autopilot *messages.Line
 }
 
+// connected holds the live vehicle connections by vehicle ID
+var connected sync.Map
+
+// Register registers the connection of a vehicle, if the vehicle is
+// already connected the previous connection is dropped only on takeover
+func Register(id string, v *Vehicle, takeover bool) error {
+	prev, loaded := connected.LoadOrStore(id, v)
+	if !loaded {
+		return nil
+	}
+
+	if !takeover {
+		return fmt.Errorf("vehicle '%s' is already connected", id)
+	}
+
+	log.Printf("Vehicle '%v' taken over by a new connection\n", id)
+	connected.Store(id, v)
+	prev.(*Vehicle).Disconnect()
+
+	return nil
+}
+
+// Disconnect drops the live connection of a vehicle
+// returns true if the vehicle was connected
+func Disconnect(id string) bool {
+	v, ok := connected.Load(id)
+	if ok {
+		v.(*Vehicle).Disconnect()
+	}
+
+	return ok
+}
+
 // New creates a new Vehicle node
 func New(ch messages.Channel) *Vehicle {
 	v := &Vehicle{
@@ -41,6 +75,7 @@ func (v *Vehicle) Start(id string, vehicle *models.Vehicle) {
 	v.getInfo(id)
 
 	ap := autopilot.Get(v.vehicle.ID)
+	ap.SetCaps(v.vehicle.Caps)
 	ap.ConnectVehicle(v.autopilot)
 
 	log.Printf("Vehicle '%v' (%v) connected\n", v.vehicle.Name, v.vehicle.ID)
@@ -69,9 +104,14 @@ func (v *Vehicle) getInfo(id string) {
 	}
 
 	vehicle.ID = id
+	// Keep the capabilities of the previous connection
+	// if the vehicle did not declare them again
+	if vehicle.Caps == nil {
+		vehicle.Caps = v.vehicle.Caps
+	}
 	v.vehicle = &vehicle
 
-	err = db.Set("vehicle:"+id, vehicle)
+	err = store.Vehicles.SetInfo(v.vehicle)
 	if err != nil {
 		log.Println("Could not save new vehicle", err)
 	}
@@ -89,7 +129,16 @@ func (v *Vehicle) run() {
 
 		case <-v.Done():
 			v.alive.Stop()
-			store.Vehicles.Disconnected(v.vehicle.ID)
+			if current, ok := connected.Load(v.vehicle.ID); ok && current == v {
+				connected.Delete(v.vehicle.ID)
+				store.Vehicles.Disconnected(v.vehicle.ID)
+
+				// the flight cannot be followed anymore
+				err := autopilot.Get(v.vehicle.ID).Push(messages.New("vehicle:disconnected", nil))
+				if err != nil {
+					log.Println(err)
+				}
+			}
 			return
 		}
 	}
@@ -34,7 +34,7 @@ func newFwdClient(token string, ws *websocket.Client) *fwdClient {
 		callbacks: callback.NewMap(),
 		parser: messages.NewParser(func(typ string) interface{} {
 			switch typ {
-			case "position", "goto":
+			case "position", "goto", "follow:target":
 				return &models.Position{}
 			case "battery":
 				return &models.Battery{}
@@ -155,7 +155,7 @@ func AddAdmin(i ChannelI) {
 	}))
 
 	a := admin.New(ch)
-	go a.Start(models.NewAdmin("0", ""), nil)
+	go a.Start(models.NewAdmin("0", "", models.RoleSupervisor, nil), nil)
 }
 
 // Connect connects the gate to the API